@@ -0,0 +1,192 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// priceRegex matches a monetary amount with 1 to 3 decimal digits and
+// optional thousands separators ("4,99", "12,5", "19.999", "1 234,50",
+// "1.234,50", "1,234.50"). A minus sign marks a refund or discount, either
+// before the amount ("-4,99", "- 4,99") or directly after it ("4,99-").
+var priceRegex = regexp.MustCompile(
+	`(?:^|\s)-\s?(?:` + amountPattern + `)|(?:` + amountPattern + `)-?`)
+
+const amountPattern = `\d{1,3}(?:\.\d{3})+,\d{2}|\d{1,3}(?:,\d{3})+\.\d{2}|\d{1,3}(?: \d{3})+[.,]\d{2}|\d+[.,]\d{1,3}`
+
+// findAmountIndexes returns the spans of the amounts in line, skipping
+// matches that are really part of a date ("12.03.2024"), a time, or a
+// longer number such as a phone number or code.
+func findAmountIndexes(line string) [][]int {
+	var spans [][]int
+	for _, loc := range priceRegex.FindAllStringIndex(line, -1) {
+		start, end := loc[0], loc[1]
+		for start < end && (line[start] == ' ' || line[start] == '\t') {
+			start++
+		}
+		if partOfLongerNumber(line, start, end) {
+			continue
+		}
+		spans = append(spans, []int{loc[0], loc[1]})
+	}
+	return splitQuantityGroups(line, spans)
+}
+
+// quantityGroupRegex matches a space-grouped amount whose first group may
+// instead be a quantity: "2 129,99" is 2129.99 or 2 × 129.99.
+var quantityGroupRegex = regexp.MustCompile(`^([1-9]\d?) (\d{3}[.,]\d{2,3})$`)
+
+// splitQuantityGroups reads a space-grouped amount such as "2 129,99" as a
+// quantity and a price when another amount on the line is their product
+// ("KAWA 2 129,99 259,98"), cutting the span down to the price. Space
+// thousands separators are otherwise kept, as in "TV 1 234,50".
+func splitQuantityGroups(line string, spans [][]int) [][]int {
+	if len(spans) < 2 {
+		return spans
+	}
+	for i, span := range spans {
+		match := quantityGroupRegex.FindStringSubmatchIndex(line[span[0]:span[1]])
+		if match == nil {
+			continue
+		}
+		quantity, _ := strconv.Atoi(line[span[0]+match[2] : span[0]+match[3]])
+		price, err := strconv.ParseFloat(normalizePriceMatch(line[span[0]+match[4]:span[1]]), 64)
+		if err != nil {
+			continue
+		}
+		for j, other := range spans {
+			total, err := strconv.ParseFloat(normalizePriceMatch(line[other[0]:other[1]]), 64)
+			if j != i && err == nil && math.Abs(float64(quantity)*price-total) < 0.005 {
+				spans[i] = []int{span[0] + match[4], span[1]}
+				break
+			}
+		}
+	}
+	return spans
+}
+
+func partOfLongerNumber(line string, start, end int) bool {
+	isDigit := func(i int) bool { return i >= 0 && i < len(line) && line[i] >= '0' && line[i] <= '9' }
+
+	if start > 0 {
+		before := line[start-1]
+		if isDigit(start-1) || (strings.IndexByte(".,/:", before) >= 0 && isDigit(start-2)) {
+			return true
+		}
+	}
+	if end < len(line) {
+		after := line[end]
+		if isDigit(end) || (strings.IndexByte(".,/:-", after) >= 0 && isDigit(end+1)) {
+			return true
+		}
+	}
+	return false
+}
+
+// findAmounts returns the amounts found in line, as matched.
+func findAmounts(line string) []string {
+	var amounts []string
+	for _, span := range findAmountIndexes(line) {
+		amounts = append(amounts, line[span[0]:span[1]])
+	}
+	return amounts
+}
+
+// removeAmounts returns line with every amount cut out.
+func removeAmounts(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, span := range findAmountIndexes(line) {
+		b.WriteString(line[last:span[0]])
+		last = span[1]
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// parseAmount returns the first monetary amount found in s.
+func parseAmount(s string) (float64, bool) {
+	matches := findAmounts(s)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	amount, err := strconv.ParseFloat(normalizePriceMatch(matches[0]), 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+// normalizePriceMatch turns a matched amount such as "4,99", "- 4,99",
+// "4,99-" or "1 234,50" into a parseable string: thousands separators are
+// dropped, the decimal separator becomes a dot and a trailing minus moves to
+// the front.
+func normalizePriceMatch(match string) string {
+	match = strings.Join(strings.Fields(match), "")
+	if strings.HasSuffix(match, "-") {
+		match = "-" + strings.TrimSuffix(match, "-")
+	}
+
+	// The last separator is the decimal one; any others group thousands
+	if decimal := strings.LastIndexAny(match, ".,"); decimal >= 0 {
+		integer := strings.NewReplacer(".", "", ",", "").Replace(match[:decimal])
+		match = integer + "." + match[decimal+1:]
+	}
+	return match
+}
+
+// dotDecimalCurrencies are printed with a decimal point ("1,234.56"). Other
+// currencies are assumed to use a decimal comma ("1.234,56", "1 234,56").
+var dotDecimalCurrencies = map[string]bool{
+	"USD": true, "GBP": true, "CHF": true, "CAD": true, "AUD": true, "NZD": true,
+	"JPY": true, "CNY": true, "HKD": true, "SGD": true, "INR": true, "ILS": true,
+	"KRW": true, "THB": true, "PHP": true, "MYR": true, "MXN": true,
+}
+
+// groupedAmountRegex matches an amount whose only separator is followed by
+// exactly three digits ("1.234", "1,234-"), which reads either as a
+// thousands group or as three decimal places.
+var groupedAmountRegex = regexp.MustCompile(`^(-?\s?[1-9]\d{0,2})([.,])(\d{3})(-?)$`)
+
+// disambiguateAmounts resolves amounts in s like "1.234" using the separator
+// conventions of currencyCode: when the separator is that currency's
+// thousands separator the amount is rewritten with explicit decimals, so
+// "1.234" becomes "1234,00" for EUR and "1,234" becomes "1234.00" for USD.
+// Everything else, and all of s when currencyCode is empty, is left as is.
+func disambiguateAmounts(s, currencyCode string) string {
+	if currencyCode == "" {
+		return s
+	}
+	decimal, thousands := ",", byte('.')
+	if dotDecimalCurrencies[currencyCode] {
+		decimal, thousands = ".", ','
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, span := range findAmountIndexes(s) {
+		match := groupedAmountRegex.FindStringSubmatch(strings.TrimLeftFunc(s[span[0]:span[1]], unicode.IsSpace))
+		if match == nil || match[2][0] != thousands {
+			continue
+		}
+		b.WriteString(s[last:span[0]])
+		b.WriteString(s[span[0] : span[1]-len(match[0])])
+		b.WriteString(match[1] + match[3] + decimal + "00" + match[4])
+		last = span[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// normalizeSignedAmount moves a trailing minus ("4,99-") to the front so the
+// sign of entity-derived prices survives.
+func normalizeSignedAmount(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasSuffix(trimmed, "-") {
+		return "-" + strings.TrimSpace(strings.TrimSuffix(trimmed, "-"))
+	}
+	return s
+}
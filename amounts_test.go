@@ -0,0 +1,188 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+func TestParseAmountSign(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   float64
+		wantOK bool
+	}{
+		{"MLEKO 3,99", 3.99, true},
+		{"RABAT -4,99", -4.99, true},
+		{"RABAT - 4,99", -4.99, true},
+		{"ZWROT 4,99-", -4.99, true},
+		{"brak kwoty", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, ok := parseAmount(tt.s)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseAmount(%q) = %v, %v, want %v, %v", tt.s, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNormalizeSignedAmount(t *testing.T) {
+	tests := []struct{ s, want string }{
+		{"4,99", "4,99"},
+		{"4,99-", "-4,99"},
+		{" 4,99 - ", "-4,99"},
+		{"-4,99", "-4,99"},
+	}
+	for _, tt := range tests {
+		if got := normalizeSignedAmount(tt.s); got != tt.want {
+			t.Errorf("normalizeSignedAmount(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRefundReceipt(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		total       string
+		wantRefund  bool
+		wantItemNeg bool
+	}{
+		{"sale", "MLEKO 3,99\nSUMA PLN 3,99", "3,99", false, false},
+		{"refund with trailing minus", "ZWROT MLEKO 3,99-\nSUMA PLN 3,99-", "3,99-", true, true},
+		{"refund with leading minus", "ZWROT MLEKO -3,99\nSUMA PLN -3,99", "-3,99", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := &documentaipb.Document{
+				Text:     tt.text,
+				Entities: []*documentaipb.Document_Entity{{Type: "receipt_total_amount", MentionText: tt.total, Confidence: 0.9}},
+			}
+			_, receipt := extractDataFromDocument(document, "", "")
+			if receipt.IsRefund != tt.wantRefund {
+				t.Errorf("IsRefund = %v, want %v (total %v)", receipt.IsRefund, tt.wantRefund, receipt.TotalAmountValue)
+			}
+			if len(receipt.Items) == 0 {
+				t.Fatalf("no items parsed from %q", tt.text)
+			}
+			if negative := receipt.Items[0].Price[0] == '-'; negative != tt.wantItemNeg {
+				t.Errorf("item price = %q, want negative %v", receipt.Items[0].Price, tt.wantItemNeg)
+			}
+		})
+	}
+}
+
+func TestFindAmounts(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"MLEKO 3,99", []string{"3,99"}},
+		{"TELEWIZOR 1 234,50", []string{"1 234,50"}},
+		{"LAPTOP 1.234,50", []string{"1.234,50"}},
+		{"LAPTOP 1,234.50", []string{"1,234.50"}},
+		{"KWD 19.999", []string{"19.999"}},
+		{"PIWO 12,5", []string{"12,5"}},
+		{"RABAT -4,99", []string{" -4,99"}},
+		{"Data 12.03.2024", nil},
+		{"Godz. 12:30", nil},
+		{"tel. 123 456 789", nil},
+		{"KAWA 2 129,99 259,98", []string{"129,99", "259,98"}},
+		{"TV 1 234,50 1 234,50", []string{"1 234,50", "1 234,50"}},
+		{"TV 2 129,99 2 129,99", []string{"2 129,99", "2 129,99"}},
+		{"NIP 123-456-32-18", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := findAmounts(tt.line)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("findAmounts(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float64
+	}{
+		{"1 234,50", 1234.50},
+		{"1.234,50", 1234.50},
+		{"1,234.50", 1234.50},
+		{"19.999", 19.999},
+		{"12,5", 12.5},
+		{"4,99-", -4.99},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got, ok := parseAmount(tt.s); !ok || got != tt.want {
+				t.Errorf("parseAmount(%q) = %v, %v, want %v", tt.s, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpaceGroupedAmountNextToQuantity(t *testing.T) {
+	items := textItems("KAWA 2 129,99 259,98")
+	if len(items) != 1 || items[0].Price != "129.99" {
+		t.Errorf("items = %+v, want one item priced 129.99", items)
+	}
+}
+
+func TestDisambiguateAmounts(t *testing.T) {
+	tests := []struct {
+		s        string
+		currency string
+		want     string
+	}{
+		{"SUMA 1.234", "", "SUMA 1.234"},
+		{"SUMA 1.234", "EUR", "SUMA 1234,00"},
+		{"SUMA 1.234", "USD", "SUMA 1.234"},
+		{"TOTAL 1,234", "USD", "TOTAL 1234.00"},
+		{"TOTAL 1,234", "PLN", "TOTAL 1,234"},
+		{"RABAT 1.500-", "EUR", "RABAT 1500,00-"},
+		// Amounts with two decimals are already unambiguous
+		{"MLEKO 3,99", "EUR", "MLEKO 3,99"},
+		{"Milk 3.99", "USD", "Milk 3.99"},
+		{"A 1.234\nB 2.500", "EUR", "A 1234,00\nB 2500,00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency+" "+tt.s, func(t *testing.T) {
+			if got := disambiguateAmounts(tt.s, tt.currency); got != tt.want {
+				t.Errorf("disambiguateAmounts(%q, %q) = %q, want %q", tt.s, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpectedCurrencyResolvesEntityAmounts(t *testing.T) {
+	tests := []struct {
+		currency     string
+		mention      string
+		want         float64
+		wantCurrency string
+		wantSource   string
+	}{
+		{"EUR", "1.234", 1234, "EUR", "expected"},
+		{"USD", "1,234", 1234, "USD", "expected"},
+		{"PLN", "1.234,56", 1234.56, "PLN", "expected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency+" "+tt.mention, func(t *testing.T) {
+			document := &documentaipb.Document{
+				Entities: []*documentaipb.Document_Entity{{Type: "receipt_total_amount", MentionText: tt.mention}},
+			}
+			_, receipt := extractDataFromDocument(document, "", tt.currency)
+			if receipt.TotalAmountValue != tt.want {
+				t.Errorf("total = %v, want %v", receipt.TotalAmountValue, tt.want)
+			}
+			if receipt.Currency != tt.wantCurrency || receipt.CurrencySource != tt.wantSource {
+				t.Errorf("currency = %q from %q, want %q from %q", receipt.Currency, receipt.CurrencySource, tt.wantCurrency, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var errArchiveTooLarge = errors.New("archive too large")
+
+// archiveExtensions are the entries processed from an archive; anything
+// else, such as manifests from the scanning appliance, is skipped.
+var archiveExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".pdf": true, ".tif": true, ".tiff": true,
+}
+
+// ArchiveResponse holds one result per processed archive entry, in archive
+// order.
+type ArchiveResponse struct {
+	Success bool                 `json:"success"`
+	Error   string               `json:"error,omitempty"`
+	Results []ArchiveEntryResult `json:"results,omitempty"`
+}
+
+// ArchiveEntryResult is the OCR response for one entry. A failed entry has
+// success false and an error, without failing the others.
+type ArchiveEntryResult struct {
+	Filename string `json:"filename"`
+	OCRResponse
+}
+
+// archiveLimits bound what an archive may expand to. Sizes are checked
+// against the bytes actually decompressed rather than the sizes the archive
+// declares, which a zip bomb can fake.
+type archiveLimits struct {
+	maxEntries    int
+	maxEntryBytes int64
+	maxTotalBytes int64
+}
+
+func archiveLimitsFromEnv() archiveLimits {
+	return archiveLimits{
+		maxEntries:    intFromEnv("ARCHIVE_MAX_ENTRIES", 100),
+		maxEntryBytes: int64(intFromEnv("ARCHIVE_MAX_ENTRY_BYTES", 20<<20)),
+		maxTotalBytes: int64(intFromEnv("ARCHIVE_MAX_TOTAL_BYTES", 200<<20)),
+	}
+}
+
+// handleArchive serves POST /api/ocr/archive, which takes a ZIP file as the
+// request body and runs OCR on each image and PDF in it. The instructions
+// query parameter applies to every entry.
+func handleArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	maxBytes := int64(intFromEnv("ARCHIVE_MAX_BYTES", 50<<20))
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendErrorResponse(w, fmt.Sprintf("archive exceeds %d bytes", maxBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		sendErrorResponse(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		sendErrorResponse(w, fmt.Sprintf("invalid ZIP archive: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	limits := archiveLimitsFromEnv()
+	entries, err := archiveEntries(archive, limits)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if len(entries) == 0 {
+		sendErrorResponse(w, "archive contains no images or PDFs", http.StatusBadRequest)
+		return
+	}
+
+	instructions := r.URL.Query().Get("instructions")
+	if instructions == "" {
+		instructions = os.Getenv("DEFAULT_INSTRUCTIONS")
+	}
+
+	// Entries are processed one at a time, so bound the archive as a whole
+	// to answer before the server's write timeout cuts the response off
+	ctx, cancel := context.WithTimeoutCause(r.Context(), durationFromEnv("MAX_TIMEOUT", 120*time.Second), errRequestTimeout)
+	defer cancel()
+
+	response := ArchiveResponse{Success: true}
+	var total int64
+	for _, entry := range entries {
+		if errors.Is(context.Cause(ctx), errRequestTimeout) {
+			result := ArchiveEntryResult{Filename: entry.Name}
+			result.Error = "not processed: archive took longer than MAX_TIMEOUT"
+			response.Results = append(response.Results, result)
+			continue
+		}
+		content, err := readArchiveEntry(entry, limits.maxEntryBytes)
+		if err == nil && total+int64(len(content)) > limits.maxTotalBytes {
+			err = fmt.Errorf("%w: entries expand to more than %d bytes", errArchiveTooLarge, limits.maxTotalBytes)
+		}
+		if errors.Is(err, errArchiveTooLarge) {
+			logWarnf("Rejected archive: %v", err)
+			sendErrorResponse(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		total += int64(len(content))
+
+		result := ArchiveEntryResult{Filename: entry.Name}
+		if err != nil {
+			result.Error = err.Error()
+			response.Results = append(response.Results, result)
+			continue
+		}
+
+		req := OCRRequest{
+			Instructions:  instructions,
+			LanguageHints: defaultLanguageHints(),
+			content:       content,
+		}
+		ocr, err := processDocument(ctx, req)
+		if errors.Is(context.Cause(ctx), errRequestTimeout) {
+			err = errRequestTimeout
+		}
+		if err != nil {
+			logWarnf("Failed to process archive entry %s: %v", entry.Name, err)
+			result.Error = newErrorOutcome(err).Message
+		} else {
+			result.OCRResponse = OCRResponse{Success: true, Text: ocr.Texts, Receipt: ocr.Receipt, NoTextDetected: ocr.NoTextDetected}
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := writeJSON(w, r, response); err != nil {
+		logErrorf("Failed to write response: %v", err)
+	}
+}
+
+// archiveEntries returns the image and PDF entries of archive, rejecting
+// archives whose declared sizes are already over the limits.
+func archiveEntries(archive *zip.Reader, limits archiveLimits) ([]*zip.File, error) {
+	var entries []*zip.File
+	var declared uint64
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+		if file.FileInfo().IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+		if !archiveExtensions[strings.ToLower(path.Ext(name))] {
+			continue
+		}
+		if len(entries) == limits.maxEntries {
+			return nil, fmt.Errorf("%w: more than %d entries", errArchiveTooLarge, limits.maxEntries)
+		}
+		if file.UncompressedSize64 > uint64(limits.maxEntryBytes) {
+			return nil, fmt.Errorf("%w: %s exceeds %d bytes", errArchiveTooLarge, file.Name, limits.maxEntryBytes)
+		}
+		declared += file.UncompressedSize64
+		if declared > uint64(limits.maxTotalBytes) {
+			return nil, fmt.Errorf("%w: entries expand to more than %d bytes", errArchiveTooLarge, limits.maxTotalBytes)
+		}
+		entries = append(entries, file)
+	}
+	return entries, nil
+}
+
+// readArchiveEntry decompresses file, stopping as soon as it exceeds
+// maxBytes.
+func readArchiveEntry(file *zip.File, maxBytes int64) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open entry: %v", err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(io.LimitReader(rc, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entry: %v", err)
+	}
+	if int64(len(content)) > maxBytes {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", errArchiveTooLarge, file.Name, maxBytes)
+	}
+	return content, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type archiveEntry struct {
+	name    string
+	content []byte
+	// declaredSize, when set, is written to the header instead of the real
+	// size, as a zip bomb does
+	declaredSize uint64
+}
+
+func buildArchive(t *testing.T, entries ...archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		var compressed bytes.Buffer
+		deflater, _ := flate.NewWriter(&compressed, flate.BestCompression)
+		deflater.Write(entry.content)
+		deflater.Close()
+
+		header := &zip.FileHeader{
+			Name:               entry.name,
+			Method:             zip.Deflate,
+			CRC32:              crc32.ChecksumIEEE(entry.content),
+			CompressedSize64:   uint64(compressed.Len()),
+			UncompressedSize64: uint64(len(entry.content)),
+		}
+		if entry.declaredSize > 0 {
+			header.UncompressedSize64 = entry.declaredSize
+		}
+		w, err := archive.CreateRaw(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(compressed.Bytes())
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func postArchive(t *testing.T, body []byte) (*httptest.ResponseRecorder, ArchiveResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleArchive(w, httptest.NewRequest(http.MethodPost, "/api/ocr/archive", bytes.NewReader(body)))
+	var response ArchiveResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response body %q: %v", w.Body, err)
+	}
+	return w, response
+}
+
+func TestHandleArchiveLimits(t *testing.T) {
+	installFakeProcessor(t, &fakeDocumentProcessor{document: testReceiptDocument()})
+	t.Setenv("ARCHIVE_MAX_ENTRIES", "3")
+	t.Setenv("ARCHIVE_MAX_ENTRY_BYTES", "4096")
+	t.Setenv("ARCHIVE_MAX_TOTAL_BYTES", "6000")
+	zeros := make([]byte, 1<<20)
+
+	tests := []struct {
+		name    string
+		entries []archiveEntry
+		want    int
+	}{
+		{"within limits", []archiveEntry{{name: "a.png", content: testPNG(t, 50)}, {name: "notes.txt", content: zeros}}, http.StatusOK},
+		{"too many entries", []archiveEntry{{name: "a.png"}, {name: "b.png"}, {name: "c.png"}, {name: "d.png"}}, http.StatusRequestEntityTooLarge},
+		{"declared entry size over the limit", []archiveEntry{{name: "bomb.png", content: zeros}}, http.StatusRequestEntityTooLarge},
+		{"declared total over the limit", []archiveEntry{{name: "a.png", content: make([]byte, 4000)}, {name: "b.png", content: make([]byte, 4000)}}, http.StatusRequestEntityTooLarge},
+		{"zip bomb under a false size", []archiveEntry{{name: "bomb.png", content: zeros, declaredSize: 100}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, response := postArchive(t, buildArchive(t, tt.entries...))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body)
+			}
+			if strings.HasPrefix(tt.name, "zip bomb") {
+				// The entry fails on its own without expanding past the limit
+				if len(response.Results) != 1 || response.Results[0].Success || response.Results[0].Error == "" {
+					t.Errorf("results = %+v, want the bomb entry to fail", response.Results)
+				}
+			}
+		})
+	}
+}
+
+func TestReadArchiveEntryStopsAtLimit(t *testing.T) {
+	data := buildArchive(t, archiveEntry{name: "a.png", content: make([]byte, 1<<20)})
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readArchiveEntry(archive.File[0], 1024); !errors.Is(err, errArchiveTooLarge) {
+		t.Errorf("readArchiveEntry error = %v, want errArchiveTooLarge", err)
+	}
+}
+
+func TestHandleArchiveDeadline(t *testing.T) {
+	installFakeProcessor(t, slowProcessor{delay: 600 * time.Millisecond})
+	t.Setenv("MAX_TIMEOUT", "1")
+
+	entries := []archiveEntry{
+		{name: "a.png", content: testPNG(t, 51)},
+		{name: "b.png", content: testPNG(t, 52)},
+		{name: "c.png", content: testPNG(t, 53)},
+	}
+	start := time.Now()
+	w, response := postArchive(t, buildArchive(t, entries...))
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("archive took %v, want it cut off at MAX_TIMEOUT", elapsed)
+	}
+	if w.Code != http.StatusOK || len(response.Results) != 3 {
+		t.Fatalf("status = %d with %d results, want 200 with 3", w.Code, len(response.Results))
+	}
+	if !response.Results[0].Success {
+		t.Errorf("first entry failed: %s", response.Results[0].Error)
+	}
+	for _, result := range response.Results[1:] {
+		if result.Success || result.Error == "" {
+			t.Errorf("%s succeeded after the deadline", result.Filename)
+		}
+	}
+}
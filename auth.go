@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// monitoringPrefixes are the networks allowed to call monitoring endpoints
+// without a key, loaded from MONITORING_CIDRS.
+var monitoringPrefixes []netip.Prefix
+
+// apiKeys returns the accepted keys from the comma-separated API_KEYS.
+func apiKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// requestAPIKey reads the key from X-API-Key or an "Authorization: Bearer"
+// header.
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+// configureMonitoringAllowlist parses the comma-separated MONITORING_CIDRS.
+// A malformed entry is an error so a typo can't silently open access.
+func configureMonitoringAllowlist() error {
+	for _, cidr := range strings.Split(os.Getenv("MONITORING_CIDRS"), ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid MONITORING_CIDRS entry %q: %v", cidr, err)
+		}
+		monitoringPrefixes = append(monitoringPrefixes, prefix.Masked())
+	}
+	if len(monitoringPrefixes) > 0 {
+		logInfof("API key checks bypassed for monitoring networks: %v", monitoringPrefixes)
+	}
+	return nil
+}
+
+// fromMonitoringNetwork reports whether the request's peer address is in
+// MONITORING_CIDRS. Forwarding headers are ignored since clients control them.
+func fromMonitoringNetwork(r *http.Request) (string, bool) {
+	if len(monitoringPrefixes) == 0 {
+		return "", false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "", false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return "", false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range monitoringPrefixes {
+		if prefix.Contains(addr) {
+			return host, true
+		}
+	}
+	return "", false
+}
+
+func validAPIKey(key string, keys []string) bool {
+	valid := false
+	for _, candidate := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(candidate)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// withAPIKey rejects requests without a valid API key when API_KEYS is set.
+// When no keys are configured, requests pass through unless required is
+// true, in which case the endpoint is disabled entirely.
+func withAPIKey(next http.HandlerFunc, required bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := apiKeys()
+		if len(keys) == 0 {
+			if required {
+				sendErrorResponse(w, "This endpoint requires API_KEYS to be configured", http.StatusForbidden)
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		if !validAPIKey(requestAPIKey(r), keys) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			sendErrorResponse(w, "Invalid or missing API key", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// withMonitoringAccess is withAPIKey, except that requests from
+// MONITORING_CIDRS skip the check. Only wrap monitoring endpoints that
+// neither change state nor process documents.
+func withMonitoringAccess(next http.HandlerFunc, required bool) http.HandlerFunc {
+	checked := withAPIKey(next, required)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if host, ok := fromMonitoringNetwork(r); ok {
+			logInfof("Request %s %s from monitoring address %s allowed without API key", r.Method, r.URL.Path, host)
+			next(w, r)
+			return
+		}
+		checked(w, r)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestMonitoringBypass(t *testing.T) {
+	t.Setenv("API_KEYS", "secret")
+	previous := monitoringPrefixes
+	monitoringPrefixes = []netip.Prefix{netip.MustParsePrefix("203.0.113.0/28")}
+	defer func() { monitoringPrefixes = previous }()
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		remote  string
+		key     string
+		want    int
+	}{
+		{"monitoring endpoint from monitoring network", withMonitoringAccess(ok, true), "203.0.113.5:4000", "", http.StatusOK},
+		{"monitoring endpoint from elsewhere", withMonitoringAccess(ok, true), "198.51.100.1:4000", "", http.StatusUnauthorized},
+		{"monitoring endpoint with key", withMonitoringAccess(ok, true), "198.51.100.1:4000", "secret", http.StatusOK},
+		{"OCR endpoint from monitoring network", withAPIKey(ok, false), "203.0.113.5:4000", "", http.StatusUnauthorized},
+		{"OCR endpoint from mapped monitoring address", withAPIKey(ok, false), "[::ffff:203.0.113.5]:4000", "", http.StatusUnauthorized},
+		{"OCR endpoint with key", withAPIKey(ok, false), "203.0.113.5:4000", "secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/ocr", nil)
+			r.RemoteAddr = tt.remote
+			if tt.key != "" {
+				r.Header.Set("X-API-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
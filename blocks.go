@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+// TextBlock is one layout block of the document with its position, for
+// clients that render or analyse the page layout.
+type TextBlock struct {
+	Page        int      `json:"page"`
+	Text        string   `json:"text"`
+	Confidence  float32  `json:"confidence"`
+	BoundingBox []Vertex `json:"bounding_box,omitempty"`
+}
+
+// Vertex is a corner of a bounding box, normalized to 0-1 of the page size.
+type Vertex struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+}
+
+// buildBlocks flattens document.Pages[].Blocks in reading order. Pages are
+// numbered from 1.
+func buildBlocks(document *documentaipb.Document) []TextBlock {
+	var blocks []TextBlock
+	for i, page := range document.Pages {
+		for _, block := range page.Blocks {
+			layout := block.Layout
+			if layout == nil {
+				continue
+			}
+			blocks = append(blocks, TextBlock{
+				Page:        i + 1,
+				Text:        strings.TrimSpace(layoutText(document.Text, layout)),
+				Confidence:  layout.Confidence,
+				BoundingBox: normalizedVertices(layout.BoundingPoly, page.Dimension),
+			})
+		}
+	}
+	return blocks
+}
+
+// layoutText resolves a layout's text anchor against the full document text.
+func layoutText(text string, layout *documentaipb.Document_Page_Layout) string {
+	if layout.TextAnchor == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, segment := range layout.TextAnchor.TextSegments {
+		start, end := segment.StartIndex, segment.EndIndex
+		if start < 0 || end > int64(len(text)) || start > end {
+			continue
+		}
+		b.WriteString(text[start:end])
+	}
+	return b.String()
+}
+
+// normalizedVertices prefers Document AI's normalized vertices and otherwise
+// scales pixel vertices by the page dimensions.
+func normalizedVertices(poly *documentaipb.BoundingPoly, dimension *documentaipb.Document_Page_Dimension) []Vertex {
+	if poly == nil {
+		return nil
+	}
+	if len(poly.NormalizedVertices) > 0 {
+		vertices := make([]Vertex, 0, len(poly.NormalizedVertices))
+		for _, v := range poly.NormalizedVertices {
+			vertices = append(vertices, Vertex{X: v.X, Y: v.Y})
+		}
+		return vertices
+	}
+	if dimension == nil || dimension.Width == 0 || dimension.Height == 0 {
+		return nil
+	}
+	vertices := make([]Vertex, 0, len(poly.Vertices))
+	for _, v := range poly.Vertices {
+		vertices = append(vertices, Vertex{X: float32(v.X) / dimension.Width, Y: float32(v.Y) / dimension.Height})
+	}
+	return vertices
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errCircuitOpen = errors.New("Document AI is unavailable, try again later")
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half_open"
+)
+
+// circuitBreaker stops sending requests to Document AI after a run of
+// consecutive backend failures. Once the cool-down has passed, a single
+// probe request is let through: success closes the breaker, failure opens it
+// again.
+type circuitBreaker struct {
+	mu            sync.Mutex
+	threshold     int
+	cooldown      time.Duration
+	state         string
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: breakerClosed}
+}
+
+// Allow reports whether a request may be sent to the backend.
+func (b *circuitBreaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probeInFlight = true
+		logInfof("Circuit breaker half-open, probing Document AI")
+		return true
+	case breakerHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	}
+	return true
+}
+
+// Record updates the breaker with the outcome of a backend call.
+func (b *circuitBreaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probeInFlight = false
+	if !isBackendFailure(err) {
+		if b.state != breakerClosed {
+			logInfof("Circuit breaker closed, Document AI recovered")
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		if b.state != breakerOpen {
+			logWarnf("Circuit breaker open after %d consecutive Document AI failures", b.failures)
+		}
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// State returns the current breaker state for reporting.
+func (b *circuitBreaker) State() string {
+	if b == nil {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return breakerHalfOpen
+	}
+	return b.state
+}
+
+// isBackendFailure reports whether err means Document AI itself is unhealthy,
+// as opposed to a problem with the request or the client going away.
+func isBackendFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// documentAIBreaker is nil when BREAKER_FAILURE_THRESHOLD is 0.
+var documentAIBreaker *circuitBreaker
+
+func configureCircuitBreaker() {
+	threshold := 5
+	if raw := os.Getenv("BREAKER_FAILURE_THRESHOLD"); raw != "" {
+		threshold, _ = strconv.Atoi(raw)
+	}
+	if threshold <= 0 {
+		return
+	}
+	cooldown := durationFromEnv("BREAKER_COOLDOWN", 30*time.Second)
+	documentAIBreaker = newCircuitBreaker(threshold, cooldown)
+	logInfof("Circuit breaker enabled (opens after %d failures, %s cool-down)", threshold, cooldown)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "down")
+	const cooldown = 20 * time.Millisecond
+
+	type step struct {
+		record    error
+		hasRecord bool
+		wait      bool
+		allow     bool
+		state     string
+	}
+	fail := func(err error) step { return step{record: err, hasRecord: true} }
+	succeed := step{hasRecord: true}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"opens at the threshold", []step{
+			fail(unavailable), {allow: true, state: breakerClosed},
+			fail(unavailable), {allow: false, state: breakerOpen},
+		}},
+		{"success resets the count", []step{
+			fail(unavailable), succeed, fail(unavailable), {allow: true, state: breakerClosed},
+		}},
+		{"client errors don't count", []step{
+			fail(status.Error(codes.InvalidArgument, "bad")), fail(context.Canceled), fail(errors.New("bad image")),
+			{allow: true, state: breakerClosed},
+		}},
+		{"half-open probe success closes", []step{
+			fail(unavailable), fail(unavailable), {wait: true, allow: true, state: breakerHalfOpen},
+			{allow: false, state: breakerHalfOpen},
+			succeed, {allow: true, state: breakerClosed},
+		}},
+		{"half-open probe failure reopens", []step{
+			fail(unavailable), fail(unavailable), {wait: true, allow: true, state: breakerHalfOpen},
+			fail(status.Error(codes.DeadlineExceeded, "slow")), {allow: false, state: breakerOpen},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := newCircuitBreaker(2, cooldown)
+			for i, s := range tt.steps {
+				if s.hasRecord {
+					breaker.Record(s.record)
+					continue
+				}
+				if s.wait {
+					time.Sleep(cooldown + 5*time.Millisecond)
+				}
+				if allowed := breaker.Allow(); allowed != s.allow {
+					t.Fatalf("step %d: Allow = %v, want %v", i, allowed, s.allow)
+				}
+				if state := breaker.State(); state != s.state {
+					t.Fatalf("step %d: State = %q, want %q", i, state, s.state)
+				}
+			}
+		})
+	}
+}
+
+func TestNilCircuitBreaker(t *testing.T) {
+	var breaker *circuitBreaker
+	breaker.Record(errors.New("ignored"))
+	if !breaker.Allow() || breaker.State() != "" {
+		t.Error("nil breaker should allow everything and report no state")
+	}
+}
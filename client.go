@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sharedProcessor keeps one Document AI client for the whole process rather
+// than dialling a new connection per request. A client can get stuck in a
+// bad state, so a watchdog recreates it after resetAfter consecutive backend
+// failures.
+type sharedProcessor struct {
+	mu         sync.Mutex
+	processor  documentProcessor
+	failures   int
+	resetAfter int
+}
+
+// Get returns the shared client, creating it on first use. The client is
+// created outside any request context so it outlives the request.
+func (s *sharedProcessor) Get() (documentProcessor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.processor == nil {
+		processor, err := newDocumentProcessor(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		s.processor = processor
+	}
+	return s.processor, nil
+}
+
+// Record updates the watchdog with the outcome of a call made with
+// processor. Outcomes for a client that was already replaced are ignored.
+func (s *sharedProcessor) Record(processor documentProcessor, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if processor != s.processor {
+		return
+	}
+	if !isBackendFailure(err) {
+		s.failures = 0
+		return
+	}
+	s.failures++
+	if s.resetAfter <= 0 || s.failures < s.resetAfter {
+		return
+	}
+
+	logWarnf("Recreating Document AI client after %d consecutive failures", s.failures)
+	old := s.processor
+	s.processor = nil
+	s.failures = 0
+	// Calls still running on the old client get the longest allowed
+	// timeout to finish before it is closed
+	time.AfterFunc(durationFromEnv("MAX_TIMEOUT", 120*time.Second), func() {
+		if err := old.Close(); err != nil {
+			logWarnf("Failed to close replaced Document AI client: %v", err)
+		}
+	})
+}
+
+// documentAIClient is the shared client used by processDocument.
+var documentAIClient = &sharedProcessor{}
+
+func configureClientWatchdog() {
+	documentAIClient.resetAfter = intFromEnv("CLIENT_RESET_AFTER_FAILURES", 3)
+}
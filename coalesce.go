@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+	"google.golang.org/protobuf/proto"
+)
+
+// coalescedCall is a Document AI call that identical requests wait on.
+type coalescedCall struct {
+	done     chan struct{}
+	response *documentaipb.ProcessResponse
+	err      error
+	// ctx is the call's context; its cause tells a call cut off at the
+	// waiters' deadline apart from one that finished
+	ctx context.Context
+
+	// Guarded by requestCoalescer.mu. deadline is the latest deadline of
+	// the requests waiting; timer cancels the call then, and is nil once a
+	// waiter without a deadline joins.
+	waiters  int
+	deadline time.Time
+	timer    *time.Timer
+	cancel   context.CancelCauseFunc
+}
+
+// requestCoalescer lets concurrent identical requests, such as a burst of
+// retried uploads, share one Document AI call. Everyone waiting gets the
+// same response or error, so the response must be treated as read-only.
+type requestCoalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+var documentAICalls = &requestCoalescer{calls: make(map[string]*coalescedCall)}
+
+// Do runs call unless one with the same key is already in flight, in which
+// case it waits for that call's result. The call isn't tied to any one
+// caller's context, since other requests may be waiting on it: it runs until
+// the latest deadline among the waiters, and is cancelled once every waiter
+// has given up. A caller whose own context ends stops waiting and gets its
+// context's error.
+func (c *requestCoalescer) Do(ctx context.Context, key string, call func(ctx context.Context) (*documentaipb.ProcessResponse, error)) (*documentaipb.ProcessResponse, error) {
+	deadline, hasDeadline := ctx.Deadline()
+
+	c.mu.Lock()
+	inFlight, ok := c.calls[key]
+	if ok && inFlight.ctx.Err() != nil {
+		// The call was already cut off and is only winding down, so its
+		// result is no use to a caller that still has time
+		ok = false
+	}
+	if !ok {
+		callCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+		inFlight = &coalescedCall{done: make(chan struct{}), ctx: callCtx, cancel: cancel}
+		if hasDeadline {
+			inFlight.deadline = deadline
+			inFlight.timer = time.AfterFunc(time.Until(deadline), func() { c.expire(inFlight) })
+		}
+		c.calls[key] = inFlight
+		go c.run(key, inFlight, callCtx, call)
+	} else {
+		logDebugf("Joining in-flight Document AI request for the same image")
+		inFlight.extendDeadline(deadline, hasDeadline)
+	}
+	inFlight.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-inFlight.done:
+		if hasDeadline && errors.Is(context.Cause(inFlight.ctx), context.DeadlineExceeded) {
+			// The call was stopped at the latest waiter deadline, so this
+			// caller's has passed too, even if its own timer hasn't fired
+			// yet. Report it the same way as any other expired request.
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return inFlight.response, inFlight.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		inFlight.waiters--
+		if inFlight.waiters == 0 {
+			// Nobody wants the answer any more; later requests start afresh
+			if c.calls[key] == inFlight {
+				delete(c.calls, key)
+			}
+			inFlight.cancel(context.Canceled)
+		}
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (c *requestCoalescer) run(key string, inFlight *coalescedCall, ctx context.Context, call func(ctx context.Context) (*documentaipb.ProcessResponse, error)) {
+	defer close(inFlight.done)
+	defer func() {
+		c.mu.Lock()
+		if c.calls[key] == inFlight {
+			delete(c.calls, key)
+		}
+		if inFlight.timer != nil {
+			inFlight.timer.Stop()
+		}
+		c.mu.Unlock()
+		inFlight.cancel(nil)
+	}()
+	// The call runs outside the handler, so a panic would otherwise take
+	// down the process
+	defer func() {
+		if rec := recover(); rec != nil {
+			logErrorf("Panic calling Document AI: %v\n%s", rec, debug.Stack())
+			inFlight.err = fmt.Errorf("internal error")
+		}
+	}()
+	inFlight.response, inFlight.err = call(ctx)
+}
+
+// expire cancels inFlight once its deadline has passed. It holds c.mu, so a
+// caller either joins before the call is cancelled, and its deadline is
+// honoured, or sees the cancelled call and starts its own.
+func (c *requestCoalescer) expire(inFlight *coalescedCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if inFlight.timer == nil || time.Now().Before(inFlight.deadline) {
+		// A waiter joined with a later deadline, or none, while the timer
+		// was firing
+		return
+	}
+	inFlight.cancel(context.DeadlineExceeded)
+}
+
+// extendDeadline lets the call run until a joining waiter's deadline when it
+// is later than the current one, or without a deadline when it has none.
+// The caller holds requestCoalescer.mu.
+func (inFlight *coalescedCall) extendDeadline(deadline time.Time, hasDeadline bool) {
+	if inFlight.timer == nil {
+		return
+	}
+	if !hasDeadline {
+		inFlight.timer.Stop()
+		inFlight.timer = nil
+		return
+	}
+	if deadline.After(inFlight.deadline) {
+		inFlight.deadline = deadline
+		inFlight.timer.Reset(time.Until(deadline))
+	}
+}
+
+// coalescingKey identifies requests that would get the same answer from
+// Document AI: the same image sent to the same processor with the same
+// options. imageHash is of the image as uploaded, so preprocessing describes
+// what was done to it before sending.
+func coalescingKey(imageHash [32]byte, preprocessing string, request *documentaipb.ProcessRequest) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%x\x00%s\x00%s\x00", imageHash, preprocessing, request.Name)
+	deterministic := proto.MarshalOptions{Deterministic: true}
+	for _, message := range []proto.Message{request.ProcessOptions, request.FieldMask} {
+		encoded, _ := deterministic.Marshal(message)
+		fmt.Fprintf(h, "%d\x00", len(encoded))
+		h.Write(encoded)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+func newTestCoalescer() *requestCoalescer {
+	return &requestCoalescer{calls: make(map[string]*coalescedCall)}
+}
+
+// waitForWaiters blocks until n callers are waiting on key.
+func waitForWaiters(t *testing.T, c *requestCoalescer, key string, n int) {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		c.mu.Lock()
+		inFlight := c.calls[key]
+		joined := inFlight != nil && inFlight.waiters == n
+		c.mu.Unlock()
+		if joined {
+			return
+		}
+	}
+	t.Fatalf("%d callers never joined the call", n)
+}
+
+func TestCoalescerSharesOneCall(t *testing.T) {
+	c := newTestCoalescer()
+	callErr := errors.New("backend failure")
+	release := make(chan struct{})
+	var calls atomic.Int32
+	call := func(ctx context.Context) (*documentaipb.ProcessResponse, error) {
+		calls.Add(1)
+		<-release
+		return nil, callErr
+	}
+
+	const waiters = 5
+	errs := make([]error, waiters)
+	var wg sync.WaitGroup
+	for i := range waiters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = c.Do(context.Background(), "key", call)
+		}()
+	}
+	waitForWaiters(t, c, "key", waiters)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("call ran %d times, want 1", n)
+	}
+	for i, err := range errs {
+		if err != callErr {
+			t.Errorf("waiter %d got %v, want %v", i, err, callErr)
+		}
+	}
+}
+
+func TestCoalescerRunsUntilLatestDeadline(t *testing.T) {
+	c := newTestCoalescer()
+	ended := make(chan time.Duration, 1)
+	start := time.Now()
+	call := func(ctx context.Context) (*documentaipb.ProcessResponse, error) {
+		<-ctx.Done()
+		ended <- time.Since(start)
+		return nil, context.Cause(ctx)
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	long, cancelLong := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancelLong()
+
+	shortErr := make(chan error, 1)
+	go func() {
+		_, err := c.Do(short, "key", call)
+		shortErr <- err
+	}()
+	waitForWaiters(t, c, "key", 1)
+	longErr := make(chan error, 1)
+	go func() {
+		_, err := c.Do(long, "key", call)
+		longErr <- err
+	}()
+
+	if err := <-shortErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("short waiter got %v, want its deadline", err)
+	}
+	if elapsed := <-ended; elapsed < 250*time.Millisecond {
+		t.Errorf("call ended after %v, want it to run until the later deadline", elapsed)
+	}
+	if err := <-longErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("long waiter got %v, want a deadline error", err)
+	}
+}
+
+// skewedContext reports a deadline slightly before the one its Done channel
+// honours, as when the coalescer's timer fires before the caller's own.
+type skewedContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c skewedContext) Deadline() (time.Time, bool) { return c.deadline, true }
+
+func TestCoalescerReportsCallerDeadline(t *testing.T) {
+	c := newTestCoalescer()
+	// The backend reports the cut-off its own way, as the gRPC client does
+	call := func(ctx context.Context) (*documentaipb.ProcessResponse, error) {
+		<-ctx.Done()
+		return nil, errors.New("rpc error: code = Canceled desc = context canceled")
+	}
+
+	timeoutCtx, cancel := context.WithTimeoutCause(context.Background(), 100*time.Millisecond, errRequestTimeout)
+	defer cancel()
+	ctx := skewedContext{Context: timeoutCtx, deadline: time.Now().Add(50 * time.Millisecond)}
+	_, err := c.Do(ctx, "key", call)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiter got %v, want its context's deadline error", err)
+	}
+	if cause := context.Cause(ctx); !errors.Is(cause, errRequestTimeout) {
+		t.Errorf("context cause = %v when Do returned, want errRequestTimeout", cause)
+	}
+}
+
+func TestCoalescerDoesNotJoinExpiredCall(t *testing.T) {
+	c := newTestCoalescer()
+	var calls atomic.Int32
+	call := func(ctx context.Context) (*documentaipb.ProcessResponse, error) {
+		if calls.Add(1) == 1 {
+			// The first call takes a while to wind down once cut off
+			<-ctx.Done()
+			time.Sleep(200 * time.Millisecond)
+			return nil, ctx.Err()
+		}
+		return &documentaipb.ProcessResponse{}, nil
+	}
+
+	// The first caller is still waiting when the call's timer fires
+	timeoutCtx, cancelShort := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancelShort()
+	short := skewedContext{Context: timeoutCtx, deadline: time.Now().Add(50 * time.Millisecond)}
+	go c.Do(short, "key", call)
+	time.Sleep(100 * time.Millisecond)
+
+	long, cancelLong := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelLong()
+	start := time.Now()
+	response, err := c.Do(long, "key", call)
+	if err != nil || response == nil {
+		t.Fatalf("second caller got %v, %v, want a fresh call's response", response, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("second caller waited %v, want it not held by the expired call", elapsed)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestCoalescerCancelsWhenLastWaiterLeaves(t *testing.T) {
+	c := newTestCoalescer()
+	cause := make(chan error, 1)
+	call := func(ctx context.Context) (*documentaipb.ProcessResponse, error) {
+		<-ctx.Done()
+		cause <- context.Cause(ctx)
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Do(ctx, "key", call)
+		done <- err
+	}()
+	waitForWaiters(t, c, "key", 1)
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("waiter got %v, want context.Canceled", err)
+	}
+	select {
+	case err := <-cause:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("call cancelled with %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("call kept running after its only waiter left")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+// positionedToken is an OCR token with the parts of its bounding box needed
+// to rebuild printed rows.
+type positionedToken struct {
+	text    string
+	left    float32
+	centerY float32
+	height  float32
+	// spaced is false when OCR found no break after the token, as in
+	// "12" "," "99"
+	spaced bool
+}
+
+// rowOrderedText rebuilds the document text row by row from token
+// positions, so a description printed on the left and a price aligned to the
+// right end up on the same line even when Document AI's reading order put
+// the columns one after the other. It returns false when the tokens carry no
+// geometry, leaving the caller to use document.Text.
+func rowOrderedText(document *documentaipb.Document) (string, bool) {
+	var rows []string
+	for _, page := range document.Pages {
+		tokens := positionedTokens(document.Text, page)
+		if len(tokens) == 0 {
+			return "", false
+		}
+		rows = append(rows, groupTokenRows(tokens)...)
+	}
+	if len(rows) == 0 {
+		return "", false
+	}
+	return strings.Join(rows, "\n"), true
+}
+
+func positionedTokens(text string, page *documentaipb.Document_Page) []positionedToken {
+	tokens := make([]positionedToken, 0, len(page.Tokens))
+	for _, token := range page.Tokens {
+		if token.Layout == nil {
+			continue
+		}
+		vertices := normalizedVertices(token.Layout.BoundingPoly, page.Dimension)
+		if len(vertices) == 0 {
+			return nil
+		}
+		tokenText := strings.TrimSpace(layoutText(text, token.Layout))
+		if tokenText == "" {
+			continue
+		}
+		left, top, bottom := vertices[0].X, vertices[0].Y, vertices[0].Y
+		for _, v := range vertices[1:] {
+			left = min(left, v.X)
+			top = min(top, v.Y)
+			bottom = max(bottom, v.Y)
+		}
+		tokens = append(tokens, positionedToken{
+			text:    tokenText,
+			left:    left,
+			centerY: (top + bottom) / 2,
+			height:  bottom - top,
+			spaced:  token.DetectedBreak != nil,
+		})
+	}
+	return tokens
+}
+
+// groupTokenRows puts tokens whose vertical centers are within half a token
+// height of each other on one row, ordered left to right, and returns the
+// rows top to bottom.
+func groupTokenRows(tokens []positionedToken) []string {
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].centerY < tokens[j].centerY
+	})
+
+	var rows [][]positionedToken
+	var rowY, rowHeight float32
+	for _, token := range tokens {
+		if len(rows) > 0 && token.centerY-rowY <= max(rowHeight, token.height)/2 {
+			rows[len(rows)-1] = append(rows[len(rows)-1], token)
+			continue
+		}
+		rows = append(rows, []positionedToken{token})
+		rowY, rowHeight = token.centerY, token.height
+	}
+
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		sort.SliceStable(row, func(i, j int) bool {
+			return row[i].left < row[j].left
+		})
+		var b strings.Builder
+		for i, token := range row {
+			if i > 0 && row[i-1].spaced {
+				b.WriteByte(' ')
+			}
+			b.WriteString(token.text)
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
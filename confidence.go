@@ -0,0 +1,55 @@
+package main
+
+// entityConfidence returns the confidence of the last entity of type
+// entityType, the one extraction keeps, or 0 when there is none.
+func entityConfidence(fields []ReceiptField, entityType string) float32 {
+	confidence := float32(0)
+	for _, field := range fields {
+		if field.Name == entityType {
+			confidence = field.Confidence
+		}
+	}
+	return confidence
+}
+
+// suppressLowConfidenceFields clears the merchant name, date and total when
+// they didn't come from a Document AI entity with at least minConfidence,
+// and lists the cleared fields in LowConfidenceFields. Values from the text
+// fallback or EXIF have no confidence, so they are cleared too. Values
+// derived from a cleared field go with it, such as the vendor match for the
+// merchant name and the formatted total and reconciliation results for the
+// total.
+func suppressLowConfidenceFields(receipt *Receipt, minConfidence float32) {
+	trusted := func(value, source, entityType string) bool {
+		return value == "" || (source == sourceDocumentAI && entityConfidence(receipt.Fields, entityType) >= minConfidence)
+	}
+
+	if !trusted(receipt.MerchantName, receipt.MerchantNameSource, "receipt_merchant_name") {
+		receipt.MerchantName = ""
+		receipt.MerchantNameSource = ""
+		receipt.MerchantNameNormalized = ""
+		receipt.VendorID = ""
+		receipt.MatchScore = 0
+		receipt.LowConfidenceFields = append(receipt.LowConfidenceFields, "merchant_name")
+	}
+	if !trusted(receipt.Date+receipt.NormalizedDate, receipt.DateSource, "receipt_date") {
+		receipt.Date = ""
+		receipt.NormalizedDate = ""
+		receipt.NormalizedTime = ""
+		receipt.DateSource = ""
+		receipt.ExifTimestamp = ""
+		receipt.LowConfidenceFields = append(receipt.LowConfidenceFields, "date")
+	}
+	if !trusted(receipt.TotalAmount, receipt.TotalAmountSource, "receipt_total_amount") {
+		receipt.TotalAmount = ""
+		receipt.TotalAmountSource = ""
+		receipt.TotalAmountValue = 0
+		receipt.TotalAmountMinor = 0
+		receipt.FormattedTotal = ""
+		receipt.IsRefund = false
+		receipt.TotalsReconcile = nil
+		receipt.TotalsDiscrepancy = 0
+		receipt.ItemsDiscrepancy = 0
+		receipt.LowConfidenceFields = append(receipt.LowConfidenceFields, "total_amount")
+	}
+}
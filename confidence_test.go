@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestSuppressLowConfidenceMerchant(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		confidence float32
+		wantVendor string
+	}{
+		{"confident entity", sourceDocumentAI, 0.95, "biedronka"},
+		{"unconfident entity", sourceDocumentAI, 0.5, ""},
+		{"text fallback", sourceTextFallback, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt := &Receipt{
+				MerchantName:           "BIEDRONKA",
+				MerchantNameSource:     tt.source,
+				MerchantNameNormalized: "Biedronka",
+				VendorID:               "biedronka",
+				MatchScore:             1,
+				Fields:                 []ReceiptField{{Name: "receipt_merchant_name", Confidence: tt.confidence}},
+			}
+			suppressLowConfidenceFields(receipt, 0.9)
+			if receipt.VendorID != tt.wantVendor {
+				t.Errorf("vendor = %q, want %q", receipt.VendorID, tt.wantVendor)
+			}
+			if cleared := tt.wantVendor == ""; cleared != (receipt.MerchantName == "") || cleared != (receipt.MatchScore == 0) {
+				t.Errorf("receipt = %+v, want the merchant name and vendor match cleared together", receipt)
+			}
+		})
+	}
+}
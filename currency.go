@@ -0,0 +1,298 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+var currencyCodeRegex = regexp.MustCompile(`\b(PLN|EUR|USD|GBP|CHF|CZK|SEK|NOK|DKK|HUF)\b`)
+
+// currencySymbols maps printed symbols to ISO codes. Order matters: "zł" is
+// checked before the more ambiguous "$".
+var currencySymbols = []struct {
+	symbol string
+	code   string
+}{
+	{"zł", "PLN"},
+	{"€", "EUR"},
+	{"£", "GBP"},
+	{"Kč", "CZK"},
+	{"$", "USD"},
+}
+
+// suffixSymbolLanguages lists languages that conventionally print the
+// currency symbol after the amount.
+var suffixSymbolLanguages = map[string]bool{
+	"pl": true, "de": true, "fr": true, "es": true, "it": true, "pt": true,
+	"cs": true, "sk": true, "hu": true, "ru": true, "uk": true, "sv": true,
+	"no": true, "nb": true, "da": true, "fi": true, "lt": true, "lv": true,
+}
+
+// detectCurrency returns the ISO 4217 code of the first currency code or
+// symbol found in text, or an empty string.
+func detectCurrency(text string) string {
+	if match := currencyCodeRegex.FindString(strings.ToUpper(text)); match != "" {
+		return match
+	}
+	lower := strings.ToLower(text)
+	for _, cs := range currencySymbols {
+		if strings.Contains(lower, strings.ToLower(cs.symbol)) {
+			return cs.code
+		}
+	}
+	return ""
+}
+
+// addressCountries maps country names, as printed in merchant addresses, to
+// ISO 3166 region codes.
+var addressCountries = []struct {
+	name   string
+	region string
+}{
+	{"polska", "PL"}, {"poland", "PL"},
+	{"deutschland", "DE"}, {"germany", "DE"},
+	{"österreich", "AT"}, {"austria", "AT"},
+	{"česká republika", "CZ"}, {"czech republic", "CZ"}, {"czechia", "CZ"},
+	{"slovensko", "SK"}, {"slovakia", "SK"},
+	{"magyarország", "HU"}, {"hungary", "HU"},
+	{"united kingdom", "GB"}, {"england", "GB"}, {"scotland", "GB"},
+	{"france", "FR"}, {"españa", "ES"}, {"spain", "ES"}, {"italia", "IT"}, {"italy", "IT"},
+	{"nederland", "NL"}, {"netherlands", "NL"}, {"schweiz", "CH"}, {"suisse", "CH"}, {"switzerland", "CH"},
+	{"sverige", "SE"}, {"sweden", "SE"}, {"norge", "NO"}, {"norway", "NO"}, {"danmark", "DK"}, {"denmark", "DK"},
+	{"usa", "US"}, {"united states", "US"},
+}
+
+// polishPostalCodeRegex matches the "00-950" format, which is distinctive
+// enough to identify a Polish address without a country name.
+var polishPostalCodeRegex = regexp.MustCompile(`(?:^|\s)\d{2}-\d{3}(?:\s|,|$)`)
+
+// countryFromAddress guesses the ISO 3166 region of a merchant address, or
+// returns an empty string.
+func countryFromAddress(address string) string {
+	lower := strings.ToLower(address)
+	for _, country := range addressCountries {
+		// Multi-word names can't be matched word by word
+		found := containsAnyWord(lower, []string{country.name})
+		if strings.Contains(country.name, " ") {
+			found = strings.Contains(lower, country.name)
+		}
+		if found {
+			return country.region
+		}
+	}
+	if polishPostalCodeRegex.MatchString(address) {
+		return "PL"
+	}
+	return ""
+}
+
+// inferCurrencyFromAddress returns the currency of the country found in the
+// merchant address, or an empty string. It is a guess, so callers should
+// only use it when the receipt itself names no currency.
+func inferCurrencyFromAddress(address string) string {
+	country := countryFromAddress(address)
+	if country == "" {
+		return ""
+	}
+	region, err := language.ParseRegion(country)
+	if err != nil {
+		return ""
+	}
+	unit, ok := currency.FromRegion(region)
+	if !ok {
+		return ""
+	}
+	return unit.String()
+}
+
+func normalizeCurrencyCode(code string) string {
+	if found := detectCurrency(code); found != "" {
+		return found
+	}
+	unit, err := currency.ParseISO(strings.TrimSpace(code))
+	if err != nil {
+		return ""
+	}
+	return unit.String()
+}
+
+// formatAmount renders amount for the given locale, with the currency
+// symbol placed the way that locale expects. currencyCode may be empty, in
+// which case only the number is formatted.
+func formatAmount(amount float64, currencyCode string, locale language.Tag) string {
+	printer := message.NewPrinter(locale)
+
+	scale := 2
+	var unit currency.Unit
+	if currencyCode != "" {
+		var err error
+		unit, err = currency.ParseISO(currencyCode)
+		if err != nil {
+			currencyCode = ""
+		} else {
+			scale, _ = currency.Standard.Rounding(unit)
+		}
+	}
+
+	formatted := printer.Sprint(number.Decimal(amount, number.Scale(scale)))
+	if currencyCode == "" {
+		return formatted
+	}
+
+	symbol := printer.Sprint(currency.NarrowSymbol(unit))
+	base, _ := locale.Base()
+	if suffixSymbolLanguages[base.String()] {
+		return formatted + " " + symbol
+	}
+	if isAlphabetic(symbol) {
+		return symbol + " " + formatted
+	}
+	return symbol + formatted
+}
+
+func isAlphabetic(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// minorUnitDigits returns how many decimal places amounts in currencyCode
+// have, e.g. 0 for JPY and 3 for KWD. Unknown currencies get 2.
+func minorUnitDigits(currencyCode string) int {
+	unit, err := currency.ParseISO(currencyCode)
+	if err != nil {
+		return 2
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+	return scale
+}
+
+// roundMoney rounds amount to the minor unit of currencyCode, with halves
+// rounded away from zero. It works on the shortest decimal form of amount,
+// so 2.675 rounds to 2.68 even though its float64 value is just below it.
+func roundMoney(amount float64, currencyCode string) float64 {
+	units, scale, ok := minorUnits(amount, currencyCode)
+	if !ok {
+		return amount
+	}
+	rounded, _ := new(big.Rat).SetFrac(units, scale).Float64()
+	return rounded
+}
+
+// toMinorUnits returns amount as an integer count of currencyCode's minor
+// unit, e.g. 4299 for 42.99 PLN, 500 for 500 JPY and 1250 for 1.25 KWD,
+// rounded the same way as roundMoney.
+func toMinorUnits(amount float64, currencyCode string) int64 {
+	units, _, ok := minorUnits(amount, currencyCode)
+	if !ok || !units.IsInt64() {
+		return 0
+	}
+	return units.Int64()
+}
+
+// formatMinorUnits writes amount rounded to currencyCode's minor unit with
+// exactly that many decimals, e.g. "42.99" for PLN, "500" for JPY and
+// "1.250" for KWD.
+func formatMinorUnits(amount float64, currencyCode string) string {
+	return strconv.FormatFloat(roundMoney(amount, currencyCode), 'f', minorUnitDigits(currencyCode), 64)
+}
+
+// minorUnits rounds amount to a whole number of currencyCode's minor unit
+// and returns that number along with the minor units per major unit.
+func minorUnits(amount float64, currencyCode string) (units, scale *big.Int, ok bool) {
+	exact, ok := new(big.Rat).SetString(strconv.FormatFloat(amount, 'f', -1, 64))
+	if !ok {
+		return nil, nil, false
+	}
+	scale = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(minorUnitDigits(currencyCode))), nil)
+	exact.Mul(exact, new(big.Rat).SetInt(scale))
+
+	half := big.NewRat(1, 2)
+	if exact.Sign() < 0 {
+		half.Neg(half)
+	}
+	exact.Add(exact, half)
+	// Quo truncates toward zero, which after adding the half rounds away
+	// from zero
+	return new(big.Int).Quo(exact.Num(), exact.Denom()), scale, true
+}
+
+// roundReceiptAmounts rounds the receipt's numeric money fields to the
+// minor unit of its currency.
+func roundReceiptAmounts(receipt *Receipt) {
+	for _, amount := range []*float64{&receipt.TotalAmountValue, &receipt.Subtotal, &receipt.Tax, &receipt.Tip} {
+		*amount = roundMoney(*amount, receipt.Currency)
+	}
+}
+
+// setMinorUnitAmounts fills the integer minor-unit copies of the receipt's
+// numeric money fields, for clients that avoid floating-point money. It runs
+// once the amounts are final.
+func setMinorUnitAmounts(receipt *Receipt) {
+	receipt.TotalAmountMinor = toMinorUnits(receipt.TotalAmountValue, receipt.Currency)
+	receipt.SubtotalMinor = toMinorUnits(receipt.Subtotal, receipt.Currency)
+	receipt.TaxMinor = toMinorUnits(receipt.Tax, receipt.Currency)
+	receipt.TipMinor = toMinorUnits(receipt.Tip, receipt.Currency)
+	receipt.ItemsPriceSumMinor = toMinorUnits(receipt.ItemsPriceSum, receipt.Currency)
+}
+
+// defaultMaxItemPrice is the largest plausible item price in currencies
+// whose major unit is worth roughly a euro or dollar.
+const defaultMaxItemPrice = 10000
+
+// highDenominationCurrencies scale defaultMaxItemPrice for currencies where
+// everyday prices run into the thousands.
+var highDenominationCurrencies = map[string]float64{
+	"JPY": 100, "HUF": 100, "ISK": 100, "KRW": 1000, "CLP": 1000, "COP": 1000, "IDR": 10000, "VND": 10000,
+}
+
+// itemPriceBounds returns the smallest and largest plausible absolute item
+// price in currencyCode. MIN_ITEM_PRICE and MAX_ITEM_PRICE override the
+// defaults, one minor unit and defaultMaxItemPrice, for every currency.
+// MIN_ITEM_PRICE may be 0 to keep any price up to the maximum; overrides that
+// would put the minimum above the maximum are ignored.
+func itemPriceBounds(currencyCode string) (float64, float64) {
+	defaultMin := math.Pow(10, -float64(minorUnitDigits(currencyCode)))
+	defaultMax := float64(defaultMaxItemPrice)
+	if scale, ok := highDenominationCurrencies[currencyCode]; ok {
+		defaultMax *= scale
+	}
+	min := nonNegativeFloatFromEnv("MIN_ITEM_PRICE", defaultMin)
+	max := floatFromEnv("MAX_ITEM_PRICE", defaultMax)
+	if min > max {
+		return defaultMin, defaultMax
+	}
+	return min, max
+}
+
+// filterImplausibleItems drops text fallback items priced outside
+// itemPriceBounds, which are usually dates, phone numbers or totals misread
+// as items, and counts them in FilteredItemCount. Document AI items are kept
+// as they are.
+func filterImplausibleItems(receipt *Receipt) {
+	min, max := itemPriceBounds(receipt.Currency)
+	kept := receipt.Items[:0]
+	for _, item := range receipt.Items {
+		if item.Source == sourceTextFallback {
+			if price, err := strconv.ParseFloat(item.Price, 64); err == nil && (math.Abs(price) < min || math.Abs(price) > max) {
+				logDebugf("Dropping item %q with implausible price %s", item.Description, item.Price)
+				receipt.FilteredItemCount++
+				continue
+			}
+		}
+		kept = append(kept, item)
+	}
+	receipt.Items = kept
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+func TestInferCurrencyFromAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"ul. Żniwna 5, 62-025 Kostrzyn", "PLN"},
+		{"Marienplatz 1, 80331 München, Deutschland", "EUR"},
+		{"10 Downing Street, London, United Kingdom", "GBP"},
+		{"Václavské náměstí 1, Praha, Česká republika", "CZK"},
+		{"Bahnhofstrasse 1, Zürich, Schweiz", "CHF"},
+		{"1 Main St, Springfield, USA", "USD"},
+		{"Main Street 12", ""},
+		// Words containing a country name are not a match
+		{"Polskastraße 3", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if got := inferCurrencyFromAddress(tt.address); got != tt.want {
+				t.Errorf("inferCurrencyFromAddress(%q) = %q, want %q", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferredCurrencyNeedsOptIn(t *testing.T) {
+	receipt := &Receipt{MerchantAddress: "Marienplatz 1, München, Deutschland"}
+	t.Setenv("INFER_CURRENCY_FROM_ADDRESS", "")
+	if got := inferredCurrency(receipt); got != "" {
+		t.Errorf("inferredCurrency without INFER_CURRENCY_FROM_ADDRESS = %q, want none", got)
+	}
+	t.Setenv("INFER_CURRENCY_FROM_ADDRESS", "true")
+	if got := inferredCurrency(receipt); got != "EUR" {
+		t.Errorf("inferredCurrency = %q, want EUR", got)
+	}
+}
+
+func TestMinorUnitDigits(t *testing.T) {
+	tests := []struct {
+		currency string
+		want     int
+	}{
+		{"PLN", 2},
+		{"EUR", 2},
+		{"JPY", 0},
+		{"KRW", 0},
+		{"KWD", 3},
+		{"BHD", 3},
+		{"", 2},
+		{"XYZ", 2},
+	}
+	for _, tt := range tests {
+		if got := minorUnitDigits(tt.currency); got != tt.want {
+			t.Errorf("minorUnitDigits(%q) = %d, want %d", tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestRoundMoney(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     float64
+	}{
+		{2.675, "EUR", 2.68},
+		{1.005, "PLN", 1.01},
+		{-1.005, "PLN", -1.01},
+		{0.1 + 0.2, "USD", 0.3},
+		{12.3, "", 12.3},
+		{1234.5, "JPY", 1235},
+		{-1234.5, "JPY", -1235},
+		{1.2345, "KWD", 1.235},
+		{1.2344, "KWD", 1.234},
+		{0, "EUR", 0},
+	}
+	for _, tt := range tests {
+		if got := roundMoney(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("roundMoney(%v, %q) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestRoundReceiptAmounts(t *testing.T) {
+	receipt := &Receipt{Currency: "JPY", TotalAmountValue: 1080.4, Subtotal: 1000.5, Tax: 79.9, Tip: 0}
+	roundReceiptAmounts(receipt)
+	if receipt.TotalAmountValue != 1080 || receipt.Subtotal != 1001 || receipt.Tax != 80 || receipt.Tip != 0 {
+		t.Errorf("amounts = %v, %v, %v, %v, want 1080, 1001, 80, 0", receipt.TotalAmountValue, receipt.Subtotal, receipt.Tax, receipt.Tip)
+	}
+}
+
+func TestItemPriceBounds(t *testing.T) {
+	tests := []struct {
+		currency         string
+		minEnv, maxEnv   string
+		wantMin, wantMax float64
+	}{
+		{"PLN", "", "", 0.01, 10000},
+		{"", "", "", 0.01, 10000},
+		{"JPY", "", "", 1, 1000000},
+		{"KWD", "", "", 0.001, 10000},
+		{"IDR", "", "", 1, 100000000},
+		{"PLN", "0.5", "500", 0.5, 500},
+		{"JPY", "", "50000", 1, 50000},
+		{"PLN", "-1", "abc", 0.01, 10000},
+		{"PLN", "0", "", 0, 10000},
+		{"PLN", "0", "500", 0, 500},
+		{"PLN", "600", "500", 0.01, 10000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency+" "+tt.minEnv+" "+tt.maxEnv, func(t *testing.T) {
+			t.Setenv("MIN_ITEM_PRICE", tt.minEnv)
+			t.Setenv("MAX_ITEM_PRICE", tt.maxEnv)
+			if min, max := itemPriceBounds(tt.currency); min != tt.wantMin || max != tt.wantMax {
+				t.Errorf("itemPriceBounds(%q) = %v, %v, want %v, %v", tt.currency, min, max, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestFilterImplausibleItems(t *testing.T) {
+	tests := []struct {
+		name         string
+		currency     string
+		items        []ReceiptItem
+		wantKept     []string
+		wantFiltered int
+	}{
+		{
+			name:     "phone number and zero price",
+			currency: "PLN",
+			items: []ReceiptItem{
+				{Description: "MLEKO", Price: "3.99", Source: sourceTextFallback},
+				{Description: "TEL", Price: "22123456.00", Source: sourceTextFallback},
+				{Description: "RABAT", Price: "-2.00", Source: sourceTextFallback},
+				{Description: "GRATIS", Price: "0.001", Source: sourceTextFallback},
+			},
+			wantKept:     []string{"MLEKO", "RABAT"},
+			wantFiltered: 2,
+		},
+		{
+			name:     "high denomination currency",
+			currency: "JPY",
+			items:    []ReceiptItem{{Description: "Ramen", Price: "12000", Source: sourceTextFallback}},
+			wantKept: []string{"Ramen"},
+		},
+		{
+			name:     "Document AI items are kept",
+			currency: "PLN",
+			items:    []ReceiptItem{{Description: "TV", Price: "25000.00", Source: sourceDocumentAI}},
+			wantKept: []string{"TV"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MIN_ITEM_PRICE", "")
+			t.Setenv("MAX_ITEM_PRICE", "")
+			receipt := &Receipt{Currency: tt.currency, Items: tt.items}
+			filterImplausibleItems(receipt)
+			var kept []string
+			for _, item := range receipt.Items {
+				kept = append(kept, item.Description)
+			}
+			if strings.Join(kept, "|") != strings.Join(tt.wantKept, "|") || receipt.FilteredItemCount != tt.wantFiltered {
+				t.Errorf("kept %q with %d filtered, want %q with %d", kept, receipt.FilteredItemCount, tt.wantKept, tt.wantFiltered)
+			}
+		})
+	}
+}
+
+func TestToMinorUnits(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     int64
+	}{
+		{42.99, "PLN", 4299},
+		{2.675, "EUR", 268},
+		{-4.995, "PLN", -500},
+		{0.1 + 0.2, "USD", 30},
+		{500, "JPY", 500},
+		{499.5, "JPY", 500},
+		{1.25, "KWD", 1250},
+		{12.3456, "BHD", 12346},
+		{12.34, "", 1234},
+		{0, "PLN", 0},
+		// Too large for an int64 count of minor units
+		{1e20, "PLN", 0},
+	}
+	for _, tt := range tests {
+		if got := toMinorUnits(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("toMinorUnits(%v, %q) = %d, want %d", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMinorUnitsScale(t *testing.T) {
+	tests := []struct {
+		currency  string
+		wantScale int64
+	}{
+		{"PLN", 100},
+		{"JPY", 1},
+		{"KWD", 1000},
+	}
+	for _, tt := range tests {
+		_, scale, ok := minorUnits(1, tt.currency)
+		if !ok || scale.Int64() != tt.wantScale {
+			t.Errorf("minorUnits scale for %s = %v, want %d", tt.currency, scale, tt.wantScale)
+		}
+	}
+}
+
+func TestFormatMinorUnits(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     string
+	}{
+		{42.99, "PLN", "42.99"},
+		{2.675, "EUR", "2.68"},
+		{500, "JPY", "500"},
+		{1.25, "KWD", "1.250"},
+		{-12.3456, "BHD", "-12.346"},
+		{7, "", "7.00"},
+	}
+	for _, tt := range tests {
+		if got := formatMinorUnits(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("formatMinorUnits(%v, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestTextTotalUsesCurrencyDecimals(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		expected  string
+		wantTotal string
+		wantValue float64
+		wantMinor int64
+	}{
+		{"two decimals", "MLEKO 3,99\nSUMA PLN 3,99", "", "3.99", 3.99, 399},
+		{"two decimals without a currency", "TEA 1,255\nTOTAL 1,255", "", "1.26", 1.26, 126},
+		{"three decimals from expected_currency", "TEA 12,345\nTOTAL 12,345", "KWD", "12.345", 12.345, 12345},
+		{"no decimals", "RAMEN 1,200\nTOTAL 1,200", "JPY", "1200", 1200, 1200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TOTAL_KEYWORDS", "")
+			_, receipt := extractDataFromDocument(&documentaipb.Document{Text: tt.text}, "shop receipt", tt.expected)
+			if receipt.TotalAmount != tt.wantTotal || receipt.TotalAmountValue != tt.wantValue || receipt.TotalAmountMinor != tt.wantMinor {
+				t.Errorf("total = %q, %v, %d minor, want %q, %v, %d", receipt.TotalAmount, receipt.TotalAmountValue, receipt.TotalAmountMinor, tt.wantTotal, tt.wantValue, tt.wantMinor)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+// DebugInfo exposes the raw Document AI entities behind a response, to help
+// diagnose why a field did or didn't extract, along with how the upload was
+// interpreted.
+type DebugInfo struct {
+	// MimeType is the detected type the image was sent to Document AI as
+	MimeType string `json:"mime_type,omitempty"`
+	// DeclaredMimeType is the type a data URI claimed, when it had one
+	DeclaredMimeType string        `json:"declared_mime_type,omitempty"`
+	Entities         []DebugEntity `json:"entities"`
+}
+
+type DebugEntity struct {
+	Type        string        `json:"type"`
+	Confidence  float32       `json:"confidence"`
+	MentionText string        `json:"mention_text,omitempty"`
+	Properties  []DebugEntity `json:"properties,omitempty"`
+}
+
+// debugResponsesAllowed reports whether clients may request debug output.
+// It is off unless ALLOW_DEBUG_RESPONSES=true, so production deployments
+// don't leak raw backend data.
+func debugResponsesAllowed() bool {
+	return os.Getenv("ALLOW_DEBUG_RESPONSES") == "true"
+}
+
+func buildDebugInfo(document *documentaipb.Document) *DebugInfo {
+	return &DebugInfo{Entities: debugEntities(document.Entities)}
+}
+
+func debugEntities(entities []*documentaipb.Document_Entity) []DebugEntity {
+	result := make([]DebugEntity, 0, len(entities))
+	for _, entity := range entities {
+		debugEntity := DebugEntity{
+			Type:        entity.Type,
+			Confidence:  entity.Confidence,
+			MentionText: entity.MentionText,
+		}
+		if len(entity.Properties) > 0 {
+			debugEntity.Properties = debugEntities(entity.Properties)
+		}
+		result = append(result, debugEntity)
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// seenHashes remembers recently processed image hashes so repeat
+// submissions can be flagged. It is bounded and evicts the least recently
+// seen hash first.
+type seenHashes struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type seenHash struct {
+	hash     string
+	lastSeen time.Time
+}
+
+func newSeenHashes(capacity int, ttl time.Duration) *seenHashes {
+	return &seenHashes{
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Check records hash as seen and reports whether it was already seen within
+// the TTL.
+func (s *seenHashes) Check(hash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if element, ok := s.entries[hash]; ok {
+		entry := element.Value.(*seenHash)
+		duplicate := now.Sub(entry.lastSeen) <= s.ttl
+		entry.lastSeen = now
+		s.order.MoveToFront(element)
+		return duplicate
+	}
+
+	s.entries[hash] = s.order.PushFront(&seenHash{hash: hash, lastSeen: now})
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*seenHash).hash)
+	}
+	return false
+}
+
+// duplicateDetector is nil unless DUPLICATE_TTL is set.
+var duplicateDetector *seenHashes
+
+func configureDuplicateDetection() {
+	ttl := durationFromEnv("DUPLICATE_TTL", 0)
+	if ttl == 0 {
+		return
+	}
+	capacity := intFromEnv("DUPLICATE_CACHE_SIZE", 10000)
+	duplicateDetector = newSeenHashes(capacity, ttl)
+	logInfof("Duplicate detection enabled (TTL %s, up to %d hashes)", ttl, capacity)
+}
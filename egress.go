@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var errImageHostForbidden = errors.New("image host is not allowed")
+
+var imageTransport = &http.Transport{
+	// No proxy: the dial-time address check needs a direct connection
+	Proxy: nil,
+	DialContext: (&net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: checkDialAddress,
+	}).DialContext,
+	TLSHandshakeTimeout: 10 * time.Second,
+	MaxIdleConns:        10,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// imageHTTPClient downloads image_url documents. Addresses are checked when
+// dialing, after DNS resolution, so a public name pointing at an internal
+// address is caught too, including on redirects. Timeout bounds each
+// attempt, including reading the body, even when the request context has no
+// deadline.
+var imageHTTPClient = &http.Client{
+	Transport: imageTransport,
+	Timeout:   60 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return checkImageURL(req.URL)
+	},
+}
+
+// configureImageTLS trusts the CA certificates in IMAGE_CA_BUNDLE, in
+// addition to the system roots, for image downloads from hosts signed by an
+// internal CA. IMAGE_TLS_INSECURE_SKIP_VERIFY=true turns verification off,
+// but only together with DEV_MODE=true so it can't be left on by accident.
+func configureImageTLS() error {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if bundle := os.Getenv("IMAGE_CA_BUNDLE"); bundle != "" {
+		pem, err := os.ReadFile(bundle)
+		if err != nil {
+			return fmt.Errorf("failed to read IMAGE_CA_BUNDLE: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("IMAGE_CA_BUNDLE %s contains no PEM certificates", bundle)
+		}
+		config.RootCAs = pool
+		logInfof("Trusting additional CA certificates from %s for image downloads", bundle)
+	}
+
+	if os.Getenv("IMAGE_TLS_INSECURE_SKIP_VERIFY") == "true" {
+		if os.Getenv("DEV_MODE") != "true" {
+			return fmt.Errorf("IMAGE_TLS_INSECURE_SKIP_VERIFY=true requires DEV_MODE=true")
+		}
+		config.InsecureSkipVerify = true
+		logWarnf("TLS certificate verification is disabled for image downloads (DEV_MODE)")
+	}
+
+	imageTransport.TLSClientConfig = config
+	return nil
+}
+
+// imageHostAllowlist returns the comma-separated IMAGE_HOST_ALLOWLIST,
+// lowercased. Entries may be exact hosts or "*.example.com" for any
+// subdomain of example.com.
+func imageHostAllowlist() []string {
+	var hosts []string
+	for _, host := range strings.Split(os.Getenv("IMAGE_HOST_ALLOWLIST"), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+func hostAllowed(host string, allowlist []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range allowlist {
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkImageURL rejects non-HTTP(S) URLs and, when IMAGE_HOST_ALLOWLIST is
+// set, hosts that aren't on it.
+func checkImageURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", errImageHostForbidden, u.Scheme)
+	}
+	if allowlist := imageHostAllowlist(); len(allowlist) > 0 && !hostAllowed(u.Hostname(), allowlist) {
+		return fmt.Errorf("%w: %s is not in IMAGE_HOST_ALLOWLIST", errImageHostForbidden, u.Hostname())
+	}
+	return nil
+}
+
+// checkDialAddress refuses connections to loopback, private, link-local and
+// other non-public addresses unless ALLOW_PRIVATE_IMAGE_HOSTS=true.
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	if os.Getenv("ALLOW_PRIVATE_IMAGE_HOSTS") == "true" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || isSharedAddress(addr) {
+		return fmt.Errorf("%w: %s is not a public address", errImageHostForbidden, addr)
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// netip doesn't count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func isSharedAddress(addr netip.Addr) bool {
+	return sharedAddressSpace.Contains(addr)
+}
+
+// defaultDownloadHeaders are the request headers clients may set for image
+// downloads with download_headers, unless DOWNLOAD_HEADER_ALLOWLIST
+// replaces them.
+var defaultDownloadHeaders = []string{"Authorization", "Referer", "Cookie", "User-Agent", "Accept", "Accept-Language"}
+
+// forbiddenDownloadHeaders control the connection or message framing, so
+// they are never accepted even when listed in DOWNLOAD_HEADER_ALLOWLIST.
+var forbiddenDownloadHeaders = map[string]bool{
+	"Host": true, "Content-Length": true, "Transfer-Encoding": true, "Connection": true,
+	"Keep-Alive": true, "Upgrade": true, "Te": true, "Trailer": true, "Proxy-Authorization": true,
+}
+
+// maxDownloadHeaderBytes bounds each download header value.
+const maxDownloadHeaderBytes = 8 << 10
+
+// validateDownloadHeaders checks download_headers against the allowlist and
+// rejects values that could smuggle extra headers or requests. Header
+// names are matched case-insensitively.
+func validateDownloadHeaders(headers map[string]string) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	allowed := make(map[string]bool)
+	for _, name := range envList("DOWNLOAD_HEADER_ALLOWLIST", defaultDownloadHeaders) {
+		allowed[http.CanonicalHeaderKey(name)] = true
+	}
+	for name, value := range headers {
+		canonical := http.CanonicalHeaderKey(name)
+		if !allowed[canonical] || forbiddenDownloadHeaders[canonical] {
+			return fmt.Errorf("download header %q is not allowed", name)
+		}
+		if len(value) > maxDownloadHeaderBytes {
+			return fmt.Errorf("download header %q is longer than %d bytes", name, maxDownloadHeaderBytes)
+		}
+		for _, r := range value {
+			if (r < ' ' && r != '\t') || r == 0x7f {
+				return fmt.Errorf("download header %q contains control characters", name)
+			}
+		}
+	}
+	return nil
+}
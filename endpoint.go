@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	documentai "cloud.google.com/go/documentai/apiv1"
+	"google.golang.org/api/option"
+)
+
+const documentAIHostSuffix = "-documentai.googleapis.com"
+
+// documentAIEndpoint returns the regional endpoint for DOCUMENT_AI_LOCATION
+// (e.g. "eu" → eu-documentai.googleapis.com:443), or DOCUMENT_AI_ENDPOINT
+// when set. An override pointing at a different Google region than the
+// configured location is an error, since processors only exist in one
+// region and requests would silently leave it.
+func documentAIEndpoint() (string, error) {
+	location := strings.ToLower(strings.TrimSpace(os.Getenv("DOCUMENT_AI_LOCATION")))
+	endpoint := strings.TrimSpace(os.Getenv("DOCUMENT_AI_ENDPOINT"))
+	if endpoint == "" {
+		if location == "" {
+			return "", fmt.Errorf("DOCUMENT_AI_LOCATION is not set")
+		}
+		return location + documentAIHostSuffix + ":443", nil
+	}
+
+	host := strings.ToLower(strings.Split(endpoint, ":")[0])
+	if region, ok := strings.CutSuffix(host, documentAIHostSuffix); ok && location != "" && region != location {
+		return "", fmt.Errorf("DOCUMENT_AI_ENDPOINT %s is in region %q but DOCUMENT_AI_LOCATION is %q", endpoint, region, location)
+	}
+	if !strings.Contains(endpoint, ":") {
+		endpoint += ":443"
+	}
+	return endpoint, nil
+}
+
+// newDocumentAIClient creates a Document AI client pinned to the regional
+// endpoint.
+func newDocumentAIClient(ctx context.Context) (*documentai.DocumentProcessorClient, error) {
+	endpoint, err := documentAIEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	return documentai.NewDocumentProcessorClient(ctx, option.WithEndpoint(endpoint))
+}
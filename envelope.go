@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// Response schema versions. Version 1 is the original shape with amounts as
+// printed strings; version 2 returns amounts and quantities as numbers.
+const (
+	responseV1 = 1
+	responseV2 = 2
+
+	latestResponseVersion = responseV2
+)
+
+// acceptVersionRegex matches a vendor media type such as
+// "application/vnd.receipt-ocr.v2+json" in the Accept header.
+var acceptVersionRegex = regexp.MustCompile(`application/vnd\.receipt-ocr\.v(\d+)\+json`)
+
+// negotiateResponseVersion picks the schema version from ?v= or, failing
+// that, the Accept header. Requests that ask for neither get version 1.
+func negotiateResponseVersion(r *http.Request) (int, error) {
+	requested := r.URL.Query().Get("v")
+	if requested == "" {
+		if match := acceptVersionRegex.FindStringSubmatch(r.Header.Get("Accept")); match != nil {
+			requested = match[1]
+		}
+	}
+	if requested == "" {
+		return responseV1, nil
+	}
+
+	version, err := strconv.Atoi(requested)
+	if err != nil || version < responseV1 || version > latestResponseVersion {
+		return 0, fmt.Errorf("unsupported response version %q: supported versions are 1 and 2", requested)
+	}
+	return version, nil
+}
+
+// OCRResponseV2 is the version 2 envelope. It reports the version it was
+// served with.
+type OCRResponseV2 struct {
+	Version int              `json:"version"`
+	Success bool             `json:"success"`
+	Text    []string         `json:"text,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	Receipt *ReceiptV2       `json:"receipt,omitempty"`
+	Debug   *DebugInfo       `json:"debug,omitempty"`
+	Blocks  []TextBlock      `json:"blocks,omitempty"`
+	Words   []WordConfidence `json:"words,omitempty"`
+
+	WordConfidence *WordConfidenceSummary `json:"word_confidence,omitempty"`
+	NoTextDetected bool                   `json:"no_text_detected,omitempty"`
+	Partial        bool                   `json:"partial,omitempty"`
+}
+
+// ReceiptV2 embeds Receipt and replaces its string amounts with numbers.
+// Fields declared here shadow the embedded ones with the same JSON name.
+type ReceiptV2 struct {
+	*Receipt
+	TotalAmount *float64        `json:"total_amount,omitempty"`
+	Items       []ReceiptItemV2 `json:"items,omitempty"`
+}
+
+type ReceiptItemV2 struct {
+	ReceiptItem
+	Quantity   *float64 `json:"quantity,omitempty"`
+	Price      *float64 `json:"price,omitempty"`
+	TotalPrice *float64 `json:"total_price,omitempty"`
+	UnitPrice  *float64 `json:"unit_price,omitempty"`
+}
+
+func newOCRResponseV2(response OCRResponse) OCRResponseV2 {
+	v2 := OCRResponseV2{
+		Version: responseV2,
+		Success: response.Success,
+		Text:    response.Text,
+		Error:   response.Error,
+		Debug:   response.Debug,
+		Blocks:  response.Blocks,
+		Words:   response.Words,
+
+		WordConfidence: response.WordConfidence,
+		NoTextDetected: response.NoTextDetected,
+		Partial:        response.Partial,
+	}
+	if response.Receipt != nil {
+		v2.Receipt = newReceiptV2(response.Receipt)
+	}
+	return v2
+}
+
+func newReceiptV2(receipt *Receipt) *ReceiptV2 {
+	v2 := &ReceiptV2{Receipt: receipt}
+	if receipt.TotalAmount != "" && receipt.TotalAmountValue != 0 {
+		total := receipt.TotalAmountValue
+		v2.TotalAmount = &total
+	} else {
+		v2.TotalAmount = optionalAmount(receipt.TotalAmount)
+	}
+	for _, item := range receipt.Items {
+		itemV2 := ReceiptItemV2{
+			ReceiptItem: item,
+			Price:       optionalAmount(item.Price),
+			TotalPrice:  optionalAmount(item.TotalPrice),
+			UnitPrice:   optionalAmount(item.UnitPrice),
+		}
+		if quantity, ok := parseQuantity(item.Quantity); ok {
+			itemV2.Quantity = &quantity
+		}
+		v2.Items = append(v2.Items, itemV2)
+	}
+	return v2
+}
+
+// optionalAmount parses an amount string, returning nil when it is empty or
+// not a number so the field is omitted rather than reported as zero.
+func optionalAmount(s string) *float64 {
+	if s == "" {
+		return nil
+	}
+	amount, ok := parseAmount(s)
+	if !ok {
+		return nil
+	}
+	return &amount
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodeQuotaExceeded is the machine-readable code sent with 429s.
+const errorCodeQuotaExceeded = "QUOTA_EXCEEDED"
+
+// statusClientClosedRequest is the non-standard status (from nginx) for a
+// request the client gave up on before it was answered. Nobody reads the
+// response, but it keeps those requests out of the 5xx counts.
+const statusClientClosedRequest = 499
+
+// errRequestTimeout is the cause of the handler's context ending when a
+// request takes longer than REQUEST_TIMEOUT overall.
+var errRequestTimeout = errors.New("request took too long to process")
+
+// errSoftDeadline is returned when the soft deadline passes before any
+// partial result is available. It is a timeout like errRequestTimeout.
+var errSoftDeadline = fmt.Errorf("%w: soft deadline passed before any result was available", errRequestTimeout)
+
+// errDocumentTooLarge is returned for documents and request bodies over
+// MAX_DOCUMENT_BYTES, see readBody.
+var errDocumentTooLarge = errors.New("document too large")
+
+// clientError marks a processDocument failure caused by the request itself,
+// such as undecodable base64 or an image URL that can't be fetched, as
+// opposed to a failure of this service or Document AI.
+type clientError struct {
+	err error
+}
+
+func (e *clientError) Error() string { return e.err.Error() }
+
+func (e *clientError) Unwrap() error { return e.err }
+
+func newClientError(format string, args ...interface{}) error {
+	return &clientError{err: fmt.Errorf(format, args...)}
+}
+
+// quotaExceededError reports that Document AI rejected a call with
+// RESOURCE_EXHAUSTED. retryAfter is the backend's suggested delay, or
+// QUOTA_RETRY_AFTER (default 30s) when it gave none.
+type quotaExceededError struct {
+	retryAfter time.Duration
+	err        error
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("Document AI quota exceeded, retry after %d seconds", int(e.retryAfter.Seconds()))
+}
+
+func (e *quotaExceededError) Unwrap() error { return e.err }
+
+// asQuotaExceeded wraps err in a quotaExceededError if it is a gRPC
+// RESOURCE_EXHAUSTED status, and returns nil otherwise.
+func asQuotaExceeded(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return nil
+	}
+	retryAfter := durationFromEnv("QUOTA_RETRY_AFTER", 30*time.Second)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+			if delay := info.RetryDelay.AsDuration(); delay > 0 {
+				retryAfter = delay
+			}
+		}
+	}
+	// Retry-After is in whole seconds, so round up: a client told to come
+	// back sooner than the backend asked would only be rejected again
+	retryAfter = (retryAfter + time.Second - 1).Truncate(time.Second)
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	return &quotaExceededError{retryAfter: retryAfter, err: err}
+}
+
+// errorStatus maps a processDocument error to its HTTP status code.
+// Anything not recognised as the client's fault is a 500.
+func errorStatus(err error) int {
+	var clientErr *clientError
+	var quotaErr *quotaExceededError
+	switch {
+	case errors.As(err, &quotaErr):
+		return http.StatusTooManyRequests
+	case errors.Is(err, errRequestTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	case errors.Is(err, errBackendBusy), errors.Is(err, errCircuitOpen):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, errDocumentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errUnsupportedFormat):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, errImageHostForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errInvalidImage), errors.As(err, &clientErr):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// quotaStatus returns a RESOURCE_EXHAUSTED status, with a RetryInfo detail
+// when delay is set.
+func quotaStatus(t *testing.T, delay time.Duration) error {
+	t.Helper()
+	st := status.New(codes.ResourceExhausted, "quota exceeded")
+	if delay != 0 {
+		var err error
+		if st, err = st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return st.Err()
+}
+
+func TestAsQuotaExceeded(t *testing.T) {
+	t.Setenv("QUOTA_RETRY_AFTER", "")
+	tests := []struct {
+		name           string
+		err            error
+		wantRetryAfter time.Duration
+	}{
+		{"backend delay", quotaStatus(t, 12*time.Second), 12 * time.Second},
+		{"fractional delay is rounded up", quotaStatus(t, 1400*time.Millisecond), 2 * time.Second},
+		{"whole seconds are kept", quotaStatus(t, 3*time.Second), 3 * time.Second},
+		{"sub-second delay", quotaStatus(t, 100*time.Millisecond), time.Second},
+		{"no RetryInfo uses the default", quotaStatus(t, 0), 30 * time.Second},
+		{"other codes", status.Error(codes.Unavailable, "down"), 0},
+		{"not a status", errors.New("boom"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := asQuotaExceeded(tt.err)
+			if tt.wantRetryAfter == 0 {
+				if err != nil {
+					t.Fatalf("asQuotaExceeded = %v, want nil", err)
+				}
+				return
+			}
+			var quotaErr *quotaExceededError
+			if !errors.As(err, &quotaErr) {
+				t.Fatalf("asQuotaExceeded = %v, want a quotaExceededError", err)
+			}
+			if quotaErr.retryAfter != tt.wantRetryAfter {
+				t.Errorf("retryAfter = %s, want %s", quotaErr.retryAfter, tt.wantRetryAfter)
+			}
+			if errorStatus(err) != http.StatusTooManyRequests {
+				t.Errorf("errorStatus = %d, want 429", errorStatus(err))
+			}
+		})
+	}
+}
+
+func TestAsQuotaExceededDefaultFromEnv(t *testing.T) {
+	t.Setenv("QUOTA_RETRY_AFTER", "90")
+	var quotaErr *quotaExceededError
+	if !errors.As(asQuotaExceeded(quotaStatus(t, 0)), &quotaErr) || quotaErr.retryAfter != 90*time.Second {
+		t.Errorf("asQuotaExceeded = %v, want a 90s retry", quotaErr)
+	}
+}
+
+func TestHandleOCRQuotaExceeded(t *testing.T) {
+	installFakeProcessor(t, &fakeDocumentProcessor{err: quotaStatus(t, 7*time.Second)})
+
+	w := postOCR(t, ocrRequestBody(t, testPNG(t, 30)))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429 (body %s)", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Retry-After"); got != "7" {
+		t.Errorf("Retry-After = %q, want 7", got)
+	}
+	var response OCRResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	if response.Code != errorCodeQuotaExceeded {
+		t.Errorf("code = %q, want %s", response.Code, errorCodeQuotaExceeded)
+	}
+}
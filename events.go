@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/googleapi"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// gcsFinalizeEvent is the notification sent when an object is created or
+// overwritten. Other events, such as deletions, are acknowledged and ignored.
+const gcsFinalizeEvent = "OBJECT_FINALIZE"
+
+// pubSubPush is the body of a Pub/Sub push request.
+type pubSubPush struct {
+	Message struct {
+		Data       string            `json:"data"`
+		Attributes map[string]string `json:"attributes"`
+		MessageID  string            `json:"messageId"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+// gcsObjectEvent is the object resource a Cloud Storage notification
+// carries as its data, with the JSON_API_V1 payload format.
+type gcsObjectEvent struct {
+	Bucket      string `json:"bucket"`
+	Name        string `json:"name"`
+	Generation  string `json:"generation"`
+	ContentType string `json:"contentType"`
+}
+
+var (
+	gcsMu      sync.Mutex
+	gcsService *storage.Service
+)
+
+// gcsClient returns the shared Cloud Storage client, creating it with the
+// default credentials on first use.
+func gcsClient() (*storage.Service, error) {
+	gcsMu.Lock()
+	defer gcsMu.Unlock()
+
+	if gcsService == nil {
+		service, err := storage.NewService(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Cloud Storage client: %v", err)
+		}
+		gcsService = service
+	}
+	return gcsService, nil
+}
+
+// withPushAuth checks the token query parameter against
+// PUBSUB_VERIFICATION_TOKEN, since Pub/Sub push subscriptions can't send an
+// API key header. Without the token the usual API key check applies.
+func withPushAuth(next http.HandlerFunc) http.HandlerFunc {
+	token := os.Getenv("PUBSUB_VERIFICATION_TOKEN")
+	if token == "" {
+		return withAPIKey(next, false)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(token)) != 1 {
+			sendErrorResponse(w, "Invalid or missing verification token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleGCSEvent serves POST /api/events/gcs, the push endpoint for a
+// Pub/Sub subscription on a bucket's Cloud Storage notifications. It OCRs
+// each new object and, with GCS_OUTPUT_BUCKET set, writes the result there
+// as <object>.json.
+//
+// Pub/Sub redelivers a message until it gets a 2xx response, so failures
+// that a retry can fix, such as a busy backend, are answered with their
+// error status, while objects that can never be processed are acknowledged
+// with 204 and logged.
+func handleGCSEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// A message that can't be read will never be readable, so it is
+	// acknowledged rather than answered with 400, which Pub/Sub would retry
+	var push pubSubPush
+	if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+		logErrorf("Dropping invalid Pub/Sub push message: %v", err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	event, err := parseGCSEvent(push)
+	if err != nil {
+		logErrorf("Dropping Pub/Sub message %s: %v", push.Message.MessageID, err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if eventType := push.Message.Attributes["eventType"]; eventType != "" && eventType != gcsFinalizeEvent {
+		logDebugf("Ignoring %s event for gs://%s/%s", eventType, event.Bucket, event.Name)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if !processableContentType(event.ContentType) {
+		logInfof("Ignoring gs://%s/%s with content type %s", event.Bucket, event.Name, event.ContentType)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	logInfof("Processing gs://%s/%s from Pub/Sub message %s", event.Bucket, event.Name, push.Message.MessageID)
+	response, err := processGCSObject(r.Context(), event)
+	if err != nil {
+		if status := errorStatus(err); status >= 500 || status == http.StatusTooManyRequests || status == statusClientClosedRequest {
+			logWarnf("Failed to process gs://%s/%s, leaving it for redelivery: %v", event.Bucket, event.Name, err)
+			sendErrorResponse(w, newErrorOutcome(err).Message, status)
+			return
+		}
+		logErrorf("Failed to process gs://%s/%s, dropping it: %v", event.Bucket, event.Name, err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := writeJSON(w, r, response); err != nil {
+		logErrorf("Failed to write response: %v", err)
+	}
+}
+
+// parseGCSEvent reads the bucket and object from a push message, from the
+// object resource in its data or, with the NONE payload format, from its
+// attributes.
+func parseGCSEvent(push pubSubPush) (gcsObjectEvent, error) {
+	var event gcsObjectEvent
+	if push.Message.Data != "" {
+		data, err := base64.StdEncoding.DecodeString(push.Message.Data)
+		if err != nil {
+			return event, fmt.Errorf("message data is not valid base64: %v", err)
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return event, fmt.Errorf("message data is not a Cloud Storage object: %v", err)
+		}
+	}
+	attributes := push.Message.Attributes
+	if event.Bucket == "" {
+		event.Bucket = attributes["bucketId"]
+	}
+	if event.Name == "" {
+		event.Name = attributes["objectId"]
+	}
+	if event.Generation == "" {
+		event.Generation = attributes["objectGeneration"]
+	}
+	if event.Bucket == "" || event.Name == "" {
+		return event, errors.New("message does not name a bucket and object")
+	}
+	return event, nil
+}
+
+// processableContentType reports whether an object may be an image or PDF.
+// Objects without a specific type are processed and left to format
+// detection; anything else, including results written back to the same
+// bucket, is skipped.
+func processableContentType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch {
+	case contentType == "", contentType == "application/octet-stream", contentType == "application/pdf":
+		return true
+	}
+	return strings.HasPrefix(contentType, "image/")
+}
+
+// processGCSObject downloads the object named in event, OCRs it and writes
+// the result to GCS_OUTPUT_BUCKET when set.
+func processGCSObject(ctx context.Context, event gcsObjectEvent) (*OCRResponse, error) {
+	// Skip the download when the object's metadata already rules it out
+	if err := checkDeclaredContentType(event.ContentType); err != nil {
+		return nil, err
+	}
+	service, err := gcsClient()
+	if err != nil {
+		return nil, err
+	}
+
+	call := service.Objects.Get(event.Bucket, event.Name).Context(ctx)
+	if generation, err := strconv.ParseInt(event.Generation, 10, 64); err == nil {
+		// Read the version that triggered the event, not a later overwrite
+		call = call.Generation(generation)
+	}
+	download, err := call.Download()
+	if err != nil {
+		return nil, gcsError("failed to download object", err)
+	}
+	defer download.Body.Close()
+	content, err := readBody(download.Body, maxDocumentBytes())
+	if errors.Is(err, errDocumentTooLarge) {
+		return nil, newClientError("object is too large: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download object: %v", err)
+	}
+
+	req := OCRRequest{
+		Instructions:  os.Getenv("DEFAULT_INSTRUCTIONS"),
+		LanguageHints: defaultLanguageHints(),
+		content:       content,
+	}
+	result, err := processDocument(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	response := &OCRResponse{Success: true, Text: result.Texts, Receipt: result.Receipt, NoTextDetected: result.NoTextDetected}
+
+	if bucket := os.Getenv("GCS_OUTPUT_BUCKET"); bucket != "" {
+		encoded, err := json.Marshal(response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode result: %v", err)
+		}
+		object := &storage.Object{Name: event.Name + ".json", ContentType: "application/json"}
+		if _, err := service.Objects.Insert(bucket, object).Media(bytes.NewReader(encoded)).Context(ctx).Do(); err != nil {
+			return nil, gcsError("failed to write result", err)
+		}
+		logInfof("Wrote result for gs://%s/%s to gs://%s/%s", event.Bucket, event.Name, bucket, object.Name)
+	}
+	return response, nil
+}
+
+// gcsError marks Cloud Storage errors that retrying won't fix, such as a
+// missing object or denied access, as client errors so the message is
+// acknowledged rather than redelivered.
+func gcsError(message string, err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code >= 400 && apiErr.Code < 500 && apiErr.Code != http.StatusTooManyRequests {
+		return newClientError("%s: %v", message, err)
+	}
+	return fmt.Errorf("%s: %v", message, err)
+}
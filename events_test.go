@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+func TestParseGCSEvent(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString([]byte(`{"bucket":"inbox","name":"receipt.jpg","generation":"7","contentType":"image/jpeg"}`))
+	tests := []struct {
+		name       string
+		data       string
+		attributes map[string]string
+		want       gcsObjectEvent
+		wantErr    bool
+	}{
+		{name: "JSON_API_V1 payload", data: data, want: gcsObjectEvent{Bucket: "inbox", Name: "receipt.jpg", Generation: "7", ContentType: "image/jpeg"}},
+		{name: "NONE payload", attributes: map[string]string{"bucketId": "inbox", "objectId": "receipt.jpg", "objectGeneration": "8"}, want: gcsObjectEvent{Bucket: "inbox", Name: "receipt.jpg", Generation: "8"}},
+		{name: "invalid base64", data: "not base64!", wantErr: true},
+		{name: "data is not an object", data: base64.StdEncoding.EncodeToString([]byte(`[1]`)), wantErr: true},
+		{name: "no object named", attributes: map[string]string{"bucketId": "inbox"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var push pubSubPush
+			push.Message.Data = tt.data
+			push.Message.Attributes = tt.attributes
+			got, err := parseGCSEvent(push)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGCSEvent error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseGCSEvent = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleGCSEventAcknowledgesUnreadableMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed body", "{not json"},
+		{"no object named", `{"message":{"attributes":{"bucketId":"inbox"},"messageId":"1"}}`},
+		{"invalid data", `{"message":{"data":"not base64!","messageId":"2"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handleGCSEvent(w, httptest.NewRequest(http.MethodPost, "/api/events/gcs", strings.NewReader(tt.body)))
+			if w.Code != http.StatusNoContent {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusNoContent)
+			}
+		})
+	}
+}
+
+func TestProcessGCSObjectSizeLimit(t *testing.T) {
+	object := strings.Repeat("x", 64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(object))
+	}))
+	defer server.Close()
+
+	service, err := storage.NewService(context.Background(), option.WithEndpoint(server.URL+"/storage/v1/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := gcsService
+	gcsService = service
+	defer func() { gcsService = previous }()
+
+	t.Setenv("MAX_DOCUMENT_BYTES", "16")
+	_, err = processGCSObject(context.Background(), gcsObjectEvent{Bucket: "inbox", Name: "receipt.jpg"})
+	var clientErr *clientError
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("processGCSObject error = %v, want a client error for the oversized object", err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+const (
+	exifTagDateTime         = 0x0132
+	exifTagExifIFDPointer   = 0x8769
+	exifTagDateTimeOriginal = 0x9003
+)
+
+// exifCaptureTime returns the DateTimeOriginal (or, failing that, DateTime)
+// recorded in a JPEG's EXIF data. Only the few tags needed are parsed.
+func exifCaptureTime(data []byte) (time.Time, bool) {
+	tiff := findExifSegment(data)
+	if tiff == nil || len(tiff) < 8 {
+		return time.Time{}, false
+	}
+
+	order := tiffByteOrder(tiff)
+	if order == nil {
+		return time.Time{}, false
+	}
+
+	ifd0 := order.Uint32(tiff[4:8])
+	var dateTime string
+	var exifIFD uint32
+	readIFD(tiff, ifd0, order, func(tag uint16, valueType uint16, count uint32, valueOffset []byte) {
+		switch tag {
+		case exifTagDateTime:
+			dateTime = readExifASCII(tiff, order, valueType, count, valueOffset)
+		case exifTagExifIFDPointer:
+			exifIFD = order.Uint32(valueOffset)
+		}
+	})
+
+	if exifIFD != 0 {
+		readIFD(tiff, exifIFD, order, func(tag uint16, valueType uint16, count uint32, valueOffset []byte) {
+			if tag == exifTagDateTimeOriginal {
+				if original := readExifASCII(tiff, order, valueType, count, valueOffset); original != "" {
+					dateTime = original
+				}
+			}
+		})
+	}
+
+	if dateTime == "" {
+		return time.Time{}, false
+	}
+	parsed, err := time.Parse("2006:01:02 15:04:05", dateTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+// tiffByteOrder reads the byte order from a TIFF header, returning nil if
+// data doesn't start with one.
+func tiffByteOrder(data []byte) binary.ByteOrder {
+	if len(data) < 8 {
+		return nil
+	}
+	switch string(data[:4]) {
+	case "II*\x00":
+		return binary.LittleEndian
+	case "MM\x00*":
+		return binary.BigEndian
+	}
+	return nil
+}
+
+// findExifSegment walks the JPEG markers and returns the TIFF payload of the
+// APP1 "Exif" segment.
+func findExifSegment(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return nil
+		}
+		marker := data[offset+1]
+		// Start of scan: image data follows, no more metadata segments
+		if marker == 0xDA {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		if length < 2 || offset+2+length > len(data) {
+			return nil
+		}
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		offset += 2 + length
+	}
+	return nil
+}
+
+func readIFD(tiff []byte, offset uint32, order binary.ByteOrder, visit func(tag, valueType uint16, count uint32, valueOffset []byte)) {
+	if int(offset)+2 > len(tiff) {
+		return
+	}
+	entries := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < entries; i++ {
+		start := int(offset) + 2 + i*12
+		if start+12 > len(tiff) {
+			return
+		}
+		entry := tiff[start : start+12]
+		visit(order.Uint16(entry[0:2]), order.Uint16(entry[2:4]), order.Uint32(entry[4:8]), entry[8:12])
+	}
+}
+
+func readExifASCII(tiff []byte, order binary.ByteOrder, valueType uint16, count uint32, valueOffset []byte) string {
+	const exifTypeASCII = 2
+	if valueType != exifTypeASCII || count == 0 {
+		return ""
+	}
+
+	var value []byte
+	if count <= 4 {
+		value = valueOffset[:count]
+	} else {
+		start := order.Uint32(valueOffset)
+		if uint64(start)+uint64(count) > uint64(len(tiff)) {
+			return ""
+		}
+		value = tiff[start : start+count]
+	}
+	return string(bytes.TrimRight(value, "\x00 "))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+// fiscalNumberRegex matches the unique number of a Polish fiscal printer,
+// three letters and eight digits printed on a line of its own at the foot of
+// the receipt, usually after the "PL" fiscal logo ("PL BFA 12345678"). An
+// optional "Nr unikatowy" label is allowed.
+var fiscalNumberRegex = regexp.MustCompile(`(?i)^(?:nr\s+unikatowy\s*:?\s*)?(?:PL\s+)?([A-Z]{3})\s?(\d{8})$`)
+
+// fiscalNumberLabels are three-letter labels whose value would otherwise
+// look like a fiscal number, as in "TEL 22123456"
+var fiscalNumberLabels = map[string]bool{"TEL": true, "FAX": true, "NIP": true, "KOD": true}
+
+// Barcode is a barcode or QR code Document AI decoded on a page.
+type Barcode struct {
+	Page   int    `json:"page"`
+	Format string `json:"format"`
+	// ValueFormat is Document AI's guess at the content, such as "url" or
+	// "text"
+	ValueFormat string `json:"value_format,omitempty"`
+	Value       string `json:"value"`
+}
+
+// extractFiscalNumber returns the fiscal printer's unique number as "ABC
+// 12345678", or an empty string. The last match wins, since the number is
+// printed at the end of the receipt.
+func extractFiscalNumber(text string) string {
+	var number string
+	for _, line := range strings.Split(text, "\n") {
+		match := fiscalNumberRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil || fiscalNumberLabels[strings.ToUpper(match[1])] {
+			continue
+		}
+		number = strings.ToUpper(match[1]) + " " + match[2]
+	}
+	return number
+}
+
+// collectBarcodes lists the barcodes Document AI decoded, in page order.
+// Pages are numbered from 1.
+func collectBarcodes(pages []*documentaipb.Document_Page) []Barcode {
+	var barcodes []Barcode
+	for i, page := range pages {
+		for _, detected := range page.DetectedBarcodes {
+			if detected.Barcode == nil || detected.Barcode.RawValue == "" {
+				continue
+			}
+			barcodes = append(barcodes, Barcode{
+				Page:        i + 1,
+				Format:      detected.Barcode.Format,
+				ValueFormat: detected.Barcode.ValueFormat,
+				Value:       detected.Barcode.RawValue,
+			})
+		}
+	}
+	return barcodes
+}
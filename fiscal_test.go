@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+func TestExtractFiscalNumber(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"SUMA PLN 3,99\nPL BFA 12345678", "BFA 12345678"},
+		{"  pl bfa12345678  ", "BFA 12345678"},
+		{"Nr unikatowy: CAB 87654321", "CAB 87654321"},
+		{"PL ABC 11111111\nPL XYZ 22222222", "XYZ 22222222"},
+		{"TEL 22123456", ""},
+		{"NIP 12345678", ""},
+		{"PL BFA 1234567", ""},
+		{"Kasa PL BFA 12345678", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := extractFiscalNumber(tt.text); got != tt.want {
+				t.Errorf("extractFiscalNumber(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectBarcodes(t *testing.T) {
+	barcode := func(format, value string) *documentaipb.Document_Page_DetectedBarcode {
+		return &documentaipb.Document_Page_DetectedBarcode{Barcode: &documentaipb.Barcode{Format: format, ValueFormat: "text", RawValue: value}}
+	}
+	pages := []*documentaipb.Document_Page{
+		{DetectedBarcodes: []*documentaipb.Document_Page_DetectedBarcode{barcode("EAN_13", "5901234123457"), barcode("QR_CODE", "")}},
+		{},
+		{DetectedBarcodes: []*documentaipb.Document_Page_DetectedBarcode{{}, barcode("QR_CODE", "https://example.com/r/1")}},
+	}
+	want := []Barcode{
+		{Page: 1, Format: "EAN_13", ValueFormat: "text", Value: "5901234123457"},
+		{Page: 3, Format: "QR_CODE", ValueFormat: "text", Value: "https://example.com/r/1"},
+	}
+	got := collectBarcodes(pages)
+	if len(got) != len(want) {
+		t.Fatalf("collectBarcodes = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("barcode %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
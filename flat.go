@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// formatFlat asks for the receipt's entity fields as one flat JSON object
+// instead of the structured response.
+const formatFlat = "flat"
+
+// validateResponseFormat rejects ?format= values other than flat up front,
+// before any processing is done.
+func validateResponseFormat(r *http.Request) error {
+	if format := r.URL.Query().Get("format"); format != "" && format != formatFlat {
+		return fmt.Errorf("unsupported format %q: only flat is supported", format)
+	}
+	return nil
+}
+
+// flattenFields maps each entity type to its value. When a type occurs more
+// than once, as line items do, the value with the highest confidence wins,
+// and of equally confident values the first.
+func flattenFields(fields []ReceiptField) map[string]string {
+	flat := make(map[string]string, len(fields))
+	best := make(map[string]float32, len(fields))
+	for _, field := range fields {
+		if confidence, ok := best[field.Name]; ok && field.Confidence <= confidence {
+			continue
+		}
+		flat[field.Name] = field.Value
+		best[field.Name] = field.Confidence
+	}
+	return flat
+}
+
+// sendFlatResponse writes the flat field map. Its keys are Document AI
+// entity types, so they are never renamed to camelCase.
+func sendFlatResponse(w http.ResponseWriter, receipt *Receipt) {
+	flat := map[string]string{}
+	if receipt != nil {
+		flat = flattenFields(receipt.Fields)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(flat); err != nil {
+		logErrorf("Failed to write response: %v", err)
+	}
+}
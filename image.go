@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"slices"
+	"strings"
+)
+
+// supportedFormats is listed in unsupported-format errors.
+const supportedFormats = "JPEG, PNG, PDF and single-page TIFF"
+
+var errUnsupportedFormat = errors.New("unsupported image format")
+
+// supportedContentTypes are the types detectMimeType recognises.
+var supportedContentTypes = []string{"image/jpeg", "image/png", "application/pdf", "image/tiff"}
+
+// allowedContentTypes are the document types accepted, from
+// ALLOWED_CONTENT_TYPES. Nil accepts every supported type.
+var allowedContentTypes map[string]bool
+
+// configureContentTypes parses the comma-separated ALLOWED_CONTENT_TYPES,
+// rejecting types the service can't process so a typo doesn't silently
+// refuse every upload.
+func configureContentTypes() error {
+	types := envList("ALLOWED_CONTENT_TYPES", nil)
+	if len(types) == 0 {
+		return nil
+	}
+	allowedContentTypes = make(map[string]bool, len(types))
+	normalized := make([]string, 0, len(types))
+	for _, contentType := range types {
+		contentType = strings.ToLower(contentType)
+		if !slices.Contains(supportedContentTypes, contentType) {
+			return fmt.Errorf("invalid ALLOWED_CONTENT_TYPES entry %q: must be one of %s", contentType, strings.Join(supportedContentTypes, ", "))
+		}
+		allowedContentTypes[contentType] = true
+		normalized = append(normalized, contentType)
+	}
+	logInfof("Accepting only documents of type %s", strings.Join(normalized, ", "))
+	return nil
+}
+
+// checkContentType rejects a detected document type that
+// ALLOWED_CONTENT_TYPES doesn't list.
+func checkContentType(mimeType string) error {
+	if allowedContentTypes != nil && !allowedContentTypes[mimeType] {
+		return fmt.Errorf("%w: %s is not accepted by this server", errUnsupportedFormat, mimeType)
+	}
+	return nil
+}
+
+// contentTypeAliases are non-standard names senders use for the supported
+// types.
+var contentTypeAliases = map[string]string{
+	"image/jpg": "image/jpeg", "image/pjpeg": "image/jpeg", "image/x-png": "image/png", "image/tif": "image/tiff",
+}
+
+// checkDeclaredContentType rejects a document by the Content-Type it was
+// sent with, such as an image_url response header, before its body is read.
+// Generic types say nothing about the content and are let through, and the
+// detected type is checked with checkContentType either way, since a
+// declared type can be wrong.
+func checkDeclaredContentType(declared string) error {
+	if allowedContentTypes == nil {
+		return nil
+	}
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(declared, ";")[0]))
+	if alias, ok := contentTypeAliases[contentType]; ok {
+		contentType = alias
+	}
+	switch contentType {
+	case "", "application/octet-stream", "binary/octet-stream":
+		return nil
+	}
+	if !allowedContentTypes[contentType] {
+		return fmt.Errorf("%w: declared type %s is not accepted by this server", errUnsupportedFormat, contentType)
+	}
+	return nil
+}
+
+var errInvalidImage = errors.New("corrupt or unsupported image")
+
+// minPDFSize is roughly the smallest byte count of a well-formed PDF.
+const minPDFSize = 64
+
+// Magic byte signatures of the accepted formats.
+var (
+	jpegSignature = []byte{0xFF, 0xD8, 0xFF}
+	pngSignature  = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+	pdfSignature  = []byte("%PDF-")
+	utf8BOM       = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// trimLeadingNoise drops UTF-8 byte order marks and ASCII whitespace that
+// some base64 pipelines prepend. No supported format starts with either.
+func trimLeadingNoise(data []byte) []byte {
+	for {
+		trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, utf8BOM), " \t\r\n")
+		if len(trimmed) == len(data) {
+			return data
+		}
+		data = trimmed
+	}
+}
+
+// detectMimeType sniffs the document type from its magic bytes, ignoring any
+// leading BOM or whitespace. GIFs and multi-frame TIFFs are rejected, since
+// Document AI may only read one frame, as is anything unrecognised.
+func detectMimeType(data []byte) (string, error) {
+	data = trimLeadingNoise(data)
+	switch {
+	case len(data) == 0:
+		return "", fmt.Errorf("%w: image is empty", errInvalidImage)
+	case bytes.HasPrefix(data, pdfSignature):
+		return "application/pdf", nil
+	case bytes.HasPrefix(data, pngSignature):
+		return "image/png", nil
+	case bytes.HasPrefix(data, jpegSignature):
+		return "image/jpeg", nil
+	case bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a")):
+		return "", fmt.Errorf("%w: GIF (supported: %s)", errUnsupportedFormat, supportedFormats)
+	}
+	if order := tiffByteOrder(data); order != nil {
+		if tiffFrameCount(data, order) > 1 {
+			return "", fmt.Errorf("%w: multi-frame TIFF (supported: %s)", errUnsupportedFormat, supportedFormats)
+		}
+		return "image/tiff", nil
+	}
+	return "", fmt.Errorf("%w: unknown format (supported: %s)", errUnsupportedFormat, supportedFormats)
+}
+
+// validateImage catches truncated or mislabelled uploads before they
+// reach Document AI, which only reports them as opaque backend errors.
+func validateImage(data []byte, mimeType string) error {
+	switch mimeType {
+	case "application/pdf":
+		if len(data) < minPDFSize {
+			return fmt.Errorf("%w: PDF is only %d bytes", errInvalidImage, len(data))
+		}
+	case "image/jpeg", "image/png":
+		if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidImage, err)
+		}
+	}
+	return nil
+}
+
+// tiffFrameCount follows the chain of image file directories, one per frame.
+func tiffFrameCount(tiff []byte, order binary.ByteOrder) int {
+	frames := 0
+	seen := map[uint32]bool{}
+	offset := order.Uint32(tiff[4:8])
+	for offset != 0 && !seen[offset] {
+		seen[offset] = true
+		if int(offset)+2 > len(tiff) {
+			break
+		}
+		frames++
+		next := int(offset) + 2 + int(order.Uint16(tiff[offset:offset+2]))*12
+		if next+4 > len(tiff) {
+			break
+		}
+		offset = order.Uint32(tiff[next : next+4])
+	}
+	return frames
+}
+
+// downscaleImage shrinks a JPEG or PNG so its longest side is at most
+// maxDimension pixels, preserving the aspect ratio. Images already within
+// the limit, PDFs, and results that wouldn't be smaller are returned as-is.
+func downscaleImage(data []byte, mimeType string, maxDimension int) ([]byte, error) {
+	if maxDimension <= 0 || (mimeType != "image/jpeg" && mimeType != "image/png") {
+		return data, nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image dimensions: %v", err)
+	}
+	if config.Width <= maxDimension && config.Height <= maxDimension {
+		return data, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	width, height := config.Width, config.Height
+	if width >= height {
+		height = height * maxDimension / width
+		width = maxDimension
+	} else {
+		width = width * maxDimension / height
+		height = maxDimension
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := resizeBox(src, width, height)
+
+	var buf bytes.Buffer
+	if mimeType == "image/png" {
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resized image: %v", err)
+	}
+
+	if buf.Len() >= len(data) {
+		logDebugf("Downscaled image is not smaller (%d >= %d bytes), keeping original", buf.Len(), len(data))
+		return data, nil
+	}
+	logInfof("Downscaled image from %dx%d to %dx%d, %d -> %d bytes (%.1f%% reduction)",
+		config.Width, config.Height, width, height, len(data), buf.Len(),
+		100*(1-float64(buf.Len())/float64(len(data))))
+	return buf.Bytes(), nil
+}
+
+// resizeBox downsamples src to width x height by averaging every source
+// pixel that falls into each destination pixel.
+func resizeBox(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	srcW, srcH := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcH / height
+		y1 := (y + 1) * srcH / height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := x * srcW / width
+			x1 := (x + 1) * srcW / width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				offset := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(rgba.Pix[offset])
+					g += int(rgba.Pix[offset+1])
+					b += int(rgba.Pix[offset+2])
+					a += int(rgba.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / n)
+			dst.Pix[offset+1] = uint8(g / n)
+			dst.Pix[offset+2] = uint8(b / n)
+			dst.Pix[offset+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// testTIFF builds a little-endian TIFF with frames empty image file
+// directories chained one after another.
+func testTIFF(frames int) []byte {
+	data := []byte("II*\x00\x08\x00\x00\x00")
+	for i := 0; i < frames; i++ {
+		next := uint32(0)
+		if i < frames-1 {
+			next = uint32(len(data) + 6)
+		}
+		data = binary.LittleEndian.AppendUint16(data, 0)
+		data = binary.LittleEndian.AppendUint32(data, next)
+	}
+	return data
+}
+
+func TestDetectMimeTypeUnsupportedFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr error
+	}{
+		{name: "GIF87a", data: []byte("GIF87a\x01\x00\x01\x00"), wantErr: errUnsupportedFormat},
+		{name: "GIF89a", data: []byte("GIF89a\x01\x00\x01\x00"), wantErr: errUnsupportedFormat},
+		{name: "single-frame TIFF", data: testTIFF(1), want: "image/tiff"},
+		{name: "multi-frame TIFF", data: testTIFF(3), wantErr: errUnsupportedFormat},
+		{name: "big-endian TIFF", data: []byte("MM\x00*\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00"), want: "image/tiff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectMimeType(tt.data)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("detectMimeType = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTIFFFrameCount(t *testing.T) {
+	for _, frames := range []int{1, 2, 5} {
+		if got := tiffFrameCount(testTIFF(frames), binary.LittleEndian); got != frames {
+			t.Errorf("tiffFrameCount = %d, want %d", got, frames)
+		}
+	}
+	// A directory pointing back at itself must not loop forever
+	looped := testTIFF(1)
+	binary.LittleEndian.PutUint32(looped[10:], 8)
+	if got := tiffFrameCount(looped, binary.LittleEndian); got != 1 {
+		t.Errorf("tiffFrameCount of a looped chain = %d, want 1", got)
+	}
+}
+
+func TestHandleOCRRejectsGIF(t *testing.T) {
+	fake := &fakeDocumentProcessor{document: testReceiptDocument()}
+	installFakeProcessor(t, fake)
+	w := postOCR(t, ocrRequestBody(t, []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+	if fake.Calls() != 0 {
+		t.Error("GIF was sent to Document AI")
+	}
+}
+
+func TestDetectMimeTypeSignatures(t *testing.T) {
+	pngHeader := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr error
+	}{
+		{name: "PDF", data: "%PDF-1.7\n", want: "application/pdf"},
+		{name: "PNG", data: pngHeader, want: "image/png"},
+		{name: "JPEG", data: "\xff\xd8\xff\xe0\x00\x10JFIF", want: "image/jpeg"},
+		{name: "PDF after a BOM", data: "\xef\xbb\xbf%PDF-1.4", want: "application/pdf"},
+		{name: "PNG after whitespace", data: " \r\n\t" + pngHeader, want: "image/png"},
+		{name: "JPEG after a BOM and newline", data: "\xef\xbb\xbf\n\xef\xbb\xbf\xff\xd8\xff\xdb", want: "image/jpeg"},
+		{name: "truncated PNG signature", data: "\x89PNG", wantErr: errUnsupportedFormat},
+		{name: "PDF without a version", data: "%PDX", wantErr: errUnsupportedFormat},
+		{name: "plain text", data: "hello world", wantErr: errUnsupportedFormat},
+		{name: "empty", data: "", wantErr: errInvalidImage},
+		{name: "only whitespace", data: "\xef\xbb\xbf \n", wantErr: errInvalidImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectMimeType([]byte(tt.data))
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("detectMimeType = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrimLeadingNoise(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"%PDF-1.7", "%PDF-1.7"},
+		{"\xef\xbb\xbf%PDF-1.7", "%PDF-1.7"},
+		{"\r\n  \xef\xbb\xbf\t%PDF-1.7", "%PDF-1.7"},
+		// Noise is only trimmed from the start
+		{"%PDF-1.7 \n", "%PDF-1.7 \n"},
+		{"\xef\xbb", "\xef\xbb"},
+	}
+	for _, tt := range tests {
+		if got := string(trimLeadingNoise([]byte(tt.data))); got != tt.want {
+			t.Errorf("trimLeadingNoise(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+// allowContentTypes sets ALLOWED_CONTENT_TYPES for the rest of the test.
+func allowContentTypes(t *testing.T, types string) {
+	t.Helper()
+	t.Setenv("ALLOWED_CONTENT_TYPES", types)
+	t.Cleanup(func() { allowedContentTypes = nil })
+	if err := configureContentTypes(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckDeclaredContentType(t *testing.T) {
+	allowContentTypes(t, "image/jpeg, Application/PDF")
+	tests := []struct {
+		declared string
+		wantErr  bool
+	}{
+		{"image/jpeg", false},
+		{"IMAGE/JPG", false},
+		{"application/pdf; charset=binary", false},
+		{"", false},
+		{"application/octet-stream", false},
+		{"image/png", true},
+		{"text/html; charset=utf-8", true},
+	}
+	for _, tt := range tests {
+		err := checkDeclaredContentType(tt.declared)
+		if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, errUnsupportedFormat)) {
+			t.Errorf("checkDeclaredContentType(%q) = %v, want error %v", tt.declared, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckDeclaredContentTypeWithoutAllowlist(t *testing.T) {
+	allowedContentTypes = nil
+	if err := checkDeclaredContentType("text/html"); err != nil {
+		t.Errorf("checkDeclaredContentType without ALLOWED_CONTENT_TYPES = %v, want nil", err)
+	}
+}
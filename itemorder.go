@@ -0,0 +1,50 @@
+package main
+
+import (
+	"sort"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+// entityPosition returns the 1-based page an entity's page anchor points at
+// and the offset of its text in the document, falling back to its
+// properties' anchors when the entity has none of its own. Entities without
+// any anchor are put on page 1.
+func entityPosition(entity *documentaipb.Document_Entity) (int, int64) {
+	page, offset := 1, int64(-1)
+	anchored := false
+	for _, candidate := range append([]*documentaipb.Document_Entity{entity}, entity.Properties...) {
+		if !anchored && candidate.PageAnchor != nil && len(candidate.PageAnchor.PageRefs) > 0 {
+			page, anchored = int(candidate.PageAnchor.PageRefs[0].Page)+1, true
+		}
+		if offset < 0 && candidate.TextAnchor != nil && len(candidate.TextAnchor.TextSegments) > 0 {
+			offset = candidate.TextAnchor.TextSegments[0].StartIndex
+		}
+	}
+	return page, offset
+}
+
+// indexItems numbers items from 1 in reading order: by page, then by where
+// their text starts. Items keep their position in the slice, so the index
+// survives sorting by price. Items read from the text are numbered in line
+// order.
+func indexItems(items []ReceiptItem) {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ia, ib := items[order[a]], items[order[b]]
+		if ia.Page != ib.Page {
+			return ia.Page < ib.Page
+		}
+		// Items without a text anchor keep their extracted order
+		if ia.offset < 0 || ib.offset < 0 {
+			return false
+		}
+		return ia.offset < ib.offset
+	})
+	for rank, i := range order {
+		items[i].Index = rank + 1
+	}
+}
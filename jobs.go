@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// Job states reported by GET /api/jobs/{id}.
+const (
+	jobProcessing = "processing"
+	jobCompleted  = "completed"
+	jobFailed     = "failed"
+)
+
+// JobStatus is returned when an async job is accepted and while it is still
+// running.
+type JobStatus struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"`
+}
+
+var (
+	results ResultStore
+	// jobResultTTL is how long a job is kept, counted from when it was last
+	// updated
+	jobResultTTL time.Duration
+)
+
+func configureJobs() error {
+	store, err := newResultStore()
+	if err != nil {
+		return err
+	}
+	results = store
+	jobResultTTL = durationFromEnv("JOB_RESULT_TTL", time.Hour)
+	return nil
+}
+
+// startJob records a processing job for req, processes it in the background
+// and returns its ID. The background context keeps the request's values but
+// not its cancellation, since the client has already been answered; instead
+// the job is cancelled after MAX_TIMEOUT and fails with errRequestTimeout.
+func startJob(ctx context.Context, req OCRRequest) (string, error) {
+	id := newJobID()
+	if err := results.Save(ctx, id, StoredResult{Status: jobProcessing}, jobResultTTL); err != nil {
+		return "", fmt.Errorf("failed to create job: %v", err)
+	}
+	logInfof("Accepted job %s", id)
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				logErrorf("Panic processing job %s: %v\n%s", id, rec, debug.Stack())
+				finishJob(ctx, id, nil, fmt.Errorf("internal error"))
+			}
+		}()
+		jobCtx, cancel := context.WithTimeoutCause(ctx, durationFromEnv("MAX_TIMEOUT", 120*time.Second), errRequestTimeout)
+		defer cancel()
+		result, err := processDocument(jobCtx, req)
+		if errors.Is(context.Cause(jobCtx), errRequestTimeout) {
+			result, err = nil, errRequestTimeout
+		}
+		if err != nil {
+			logErrorf("Job %s failed: %v", id, err)
+		}
+		finishJob(ctx, id, result, err)
+	}()
+	return id, nil
+}
+
+// finishJob stores the outcome of a job, restarting its TTL so results can
+// be fetched for the full TTL after completion.
+func finishJob(ctx context.Context, id string, result *ocrResult, err error) {
+	stored := StoredResult{Status: jobCompleted, Result: result}
+	if err != nil {
+		stored = StoredResult{Status: jobFailed, Error: newErrorOutcome(err)}
+	}
+	if err := results.Save(ctx, id, stored, jobResultTTL); err != nil {
+		logErrorf("Failed to save result of job %s: %v", id, err)
+	}
+}
+
+// newJobID returns a random ID that is long enough that job results can't be
+// fetched by guessing.
+func newJobID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// handleJob serves GET /api/jobs/{id}: 202 with the status while the job runs,
+// then the same response the synchronous request would have returned.
+func handleJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	version, err := negotiateResponseVersion(r)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	if err := validateResponseFormat(r); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := r.PathValue("id")
+	stored, err := results.Get(r.Context(), id)
+	if errors.Is(err, errResultNotFound) {
+		sendErrorResponse(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logErrorf("Failed to load job %s: %v", id, err)
+		sendErrorResponse(w, "Failed to load job", http.StatusInternalServerError)
+		return
+	}
+
+	switch stored.Status {
+	case jobProcessing:
+		sendJobStatus(w, r, id, stored.Status)
+	case jobFailed:
+		sendErrorOutcome(w, stored.Error)
+	default:
+		sendOCRResponse(w, r, stored.Result, version)
+	}
+}
+
+func sendJobStatus(w http.ResponseWriter, r *http.Request, id, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/jobs/"+id)
+	w.WriteHeader(http.StatusAccepted)
+	writeJSON(w, r, JobStatus{JobID: id, Status: status})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// slowProcessor answers each call after delay, so a job of several calls
+// outlasts a timeout that each call alone stays within.
+type slowProcessor struct {
+	delay time.Duration
+}
+
+func (p slowProcessor) ProcessDocument(ctx context.Context, req *documentaipb.ProcessRequest, opts ...gax.CallOption) (*documentaipb.ProcessResponse, error) {
+	select {
+	case <-time.After(p.delay):
+		return &documentaipb.ProcessResponse{Document: testReceiptDocument()}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (slowProcessor) Close() error { return nil }
+
+func TestJobTimesOutAfterMaxTimeout(t *testing.T) {
+	installFakeProcessor(t, slowProcessor{delay: 600 * time.Millisecond})
+	t.Setenv("MAX_TIMEOUT", "1")
+	previous, previousTTL := results, jobResultTTL
+	results, jobResultTTL = newMemoryResultStore(10), time.Minute
+	defer func() { results, jobResultTTL = previous, previousTTL }()
+
+	// The request context is already gone by the time the job runs
+	ctx, cancel := context.WithCancel(context.Background())
+	images := []ImageSource{
+		{Base64Image: base64.StdEncoding.EncodeToString(testPNG(t, 40))},
+		{Base64Image: base64.StdEncoding.EncodeToString(testPNG(t, 41))},
+		{Base64Image: base64.StdEncoding.EncodeToString(testPNG(t, 42))},
+	}
+	id, err := startJob(ctx, OCRRequest{Images: images})
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+		stored, err := results.Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status == jobProcessing {
+			continue
+		}
+		if stored.Status != jobFailed || stored.Error == nil || stored.Error.Status != http.StatusGatewayTimeout {
+			t.Fatalf("job = %+v with error %+v, want failed with 504", stored, stored.Error)
+		}
+		return
+	}
+	t.Fatal("job still running long after MAX_TIMEOUT")
+}
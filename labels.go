@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	subtotalLabels = []string{"subtotal", "sub-total", "sub total", "podsuma"}
+	// taxLabels are matched as whole words, so "opodatkowana" (taxable) on
+	// Polish receipts isn't taken for the tax itself
+	taxLabels = []string{"vat", "ptu", "tax", "podatek"}
+	// taxInclusiveMarkers turn a line mentioning tax back into a total, as in
+	// "TOTAL INCL. VAT"
+	taxInclusiveMarkers = []string{"incl", "inkl", "w tym"}
+)
+
+// labeledAmounts are the subtotal, tax and total found next to their labels
+// in receipt text.
+type labeledAmounts struct {
+	subtotal, tax, total          float64
+	hasSubtotal, hasTax, hasTotal bool
+	// labelLines holds the lines that carry a label or the amount for the
+	// label on the line above, so they aren't parsed as items
+	labelLines map[int]bool
+}
+
+// findLabeledAmounts pairs each subtotal, tax and total label in lines with
+// the nearest amount: the first one after the label on the same line, else
+// the last one before it, else the amount on the next line when that line
+// has no label of its own. Labels are checked in that order, so "SUMA PTU"
+// is the tax rather than the total. The first subtotal wins, while for tax
+// and total the largest amount wins, since receipts list per-rate taxes and
+// partial sums before the final figure.
+func findLabeledAmounts(lines []string, totalKeywords []string) labeledAmounts {
+	found := labeledAmounts{labelLines: make(map[int]bool)}
+	for i, line := range lines {
+		kind, labelEnd := amountLabel(line, totalKeywords)
+		if kind == "" {
+			continue
+		}
+		found.labelLines[i] = true
+
+		amount, ok := amountNearLabel(line, labelEnd)
+		if !ok && i+1 < len(lines) {
+			if nextKind, _ := amountLabel(lines[i+1], totalKeywords); nextKind == "" {
+				if amount, ok = amountNearLabel(lines[i+1], 0); ok {
+					found.labelLines[i+1] = true
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		switch kind {
+		case "subtotal":
+			if !found.hasSubtotal {
+				found.subtotal, found.hasSubtotal = amount, true
+			}
+		case "tax":
+			if !found.hasTax || amount > found.tax {
+				found.tax, found.hasTax = amount, true
+			}
+		case "total":
+			// Refund receipts have a negative total
+			if !found.hasTotal || math.Abs(amount) > math.Abs(found.total) {
+				found.total, found.hasTotal = amount, true
+			}
+		}
+	}
+	return found
+}
+
+// amountLabel returns which label line carries, if any, and the byte offset
+// where the label ends.
+func amountLabel(line string, totalKeywords []string) (string, int) {
+	lower := strings.ToLower(line)
+	if end := labelEnd(lower, subtotalLabels, false); end >= 0 {
+		return "subtotal", end
+	}
+	if end := labelEnd(lower, taxLabels, true); end >= 0 && !containsAnyKeyword(lower, taxInclusiveMarkers) {
+		return "tax", end
+	}
+	for _, keyword := range totalKeywords {
+		if end := labelEnd(lower, []string{strings.ToLower(keyword)}, false); end >= 0 {
+			return "total", end
+		}
+	}
+	return "", -1
+}
+
+// labelEnd finds the first of labels in lower and returns the offset just
+// past it, or -1. With wholeWord, the match may not touch other letters.
+func labelEnd(lower string, labels []string, wholeWord bool) int {
+	best := -1
+	for _, label := range labels {
+		for offset := 0; offset < len(lower); {
+			index := strings.Index(lower[offset:], label)
+			if index < 0 {
+				break
+			}
+			start, end := offset+index, offset+index+len(label)
+			if !wholeWord || (!letterBefore(lower, start) && !letterAfter(lower, end)) {
+				if best < 0 || end < best {
+					best = end
+				}
+				break
+			}
+			offset = end
+		}
+	}
+	return best
+}
+
+func letterBefore(s string, i int) bool {
+	r, size := utf8.DecodeLastRuneInString(s[:i])
+	return size > 0 && unicode.IsLetter(r)
+}
+
+func letterAfter(s string, i int) bool {
+	r, size := utf8.DecodeRuneInString(s[i:])
+	return size > 0 && unicode.IsLetter(r)
+}
+
+// amountNearLabel returns the first amount in line starting at or after
+// from, or the last one before it. Percentages such as a "23,00%" VAT rate
+// are skipped.
+func amountNearLabel(line string, from int) (float64, bool) {
+	var before []int
+	for _, span := range findAmountIndexes(line) {
+		if strings.HasPrefix(strings.TrimSpace(line[span[1]:]), "%") {
+			continue
+		}
+		if span[0] >= from {
+			return parseAmountSpan(line, span)
+		}
+		before = span
+	}
+	if before == nil {
+		return 0, false
+	}
+	return parseAmountSpan(line, before)
+}
+
+func parseAmountSpan(line string, span []int) (float64, bool) {
+	amount, err := strconv.ParseFloat(normalizePriceMatch(line[span[0]:span[1]]), 64)
+	return amount, err == nil
+}
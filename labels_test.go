@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindLabeledAmounts(t *testing.T) {
+	tests := []struct {
+		name                             string
+		text                             string
+		hasSubtotal, hasTax, hasTotal    bool
+		wantSubtotal, wantTax, wantTotal float64
+	}{
+		{
+			name:        "labels with amounts on the same line",
+			text:        "SUBTOTAL 10,00\nVAT 23% 2,30\nTOTAL 12,30",
+			hasSubtotal: true, wantSubtotal: 10,
+			hasTax: true, wantTax: 2.3,
+			hasTotal: true, wantTotal: 12.3,
+		},
+		{
+			name:     "amount on the next line",
+			text:     "SUMA PLN\n45,99",
+			hasTotal: true, wantTotal: 45.99,
+		},
+		{
+			name:   "tax label before total keyword",
+			text:   "SUMA PTU 4,60",
+			hasTax: true, wantTax: 4.6,
+		},
+		{
+			name:   "largest tax wins",
+			text:   "PTU A 23,00% 1,20\nPTU B 8,00% 0,40\nSUMA PTU 1,60",
+			hasTax: true, wantTax: 1.6,
+		},
+		{
+			name:     "total including VAT",
+			text:     "TOTAL INCL. VAT 9,99",
+			hasTotal: true, wantTotal: 9.99,
+		},
+		{
+			name: "taxable amount isn't tax",
+			text: "SPRZEDAŻ OPODATKOWANA A 20,00",
+		},
+		{
+			name:     "negative total on a refund",
+			text:     "SUMA 5,00\nSUMA -12,50",
+			hasTotal: true, wantTotal: -12.5,
+		},
+		{
+			name:        "first subtotal wins",
+			text:        "PODSUMA 8,00\nPODSUMA 9,00",
+			hasSubtotal: true, wantSubtotal: 8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findLabeledAmounts(strings.Split(tt.text, "\n"), defaultTotalKeywords)
+			if got.hasSubtotal != tt.hasSubtotal || got.subtotal != tt.wantSubtotal {
+				t.Errorf("subtotal = %v (%v), want %v (%v)", got.subtotal, got.hasSubtotal, tt.wantSubtotal, tt.hasSubtotal)
+			}
+			if got.hasTax != tt.hasTax || got.tax != tt.wantTax {
+				t.Errorf("tax = %v (%v), want %v (%v)", got.tax, got.hasTax, tt.wantTax, tt.hasTax)
+			}
+			if got.hasTotal != tt.hasTotal || got.total != tt.wantTotal {
+				t.Errorf("total = %v (%v), want %v (%v)", got.total, got.hasTotal, tt.wantTotal, tt.hasTotal)
+			}
+		})
+	}
+}
+
+func TestAmountNearLabel(t *testing.T) {
+	tests := []struct {
+		line   string
+		from   int
+		want   float64
+		wantOK bool
+	}{
+		{"TOTAL 12,30", 5, 12.3, true},
+		{"12,30 TOTAL", 11, 12.3, true},
+		{"VAT 23,00% 2,30", 3, 2.3, true},
+		{"TOTAL", 5, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := amountNearLabel(tt.line, tt.from)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("amountNearLabel(%q, %d) = %v, %v, want %v, %v", tt.line, tt.from, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
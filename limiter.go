@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+var errBackendBusy = errors.New("too many concurrent requests to Document AI, try again later")
+
+// documentAILimiter bounds the number of in-flight ProcessDocument calls.
+// A nil semaphore means no limit is enforced.
+var documentAILimiter struct {
+	semaphore    chan struct{}
+	reject       bool
+	queueTimeout time.Duration
+}
+
+func configureConcurrencyLimit() {
+	maxConcurrency, _ := strconv.Atoi(os.Getenv("MAX_CONCURRENCY"))
+	if maxConcurrency <= 0 {
+		return
+	}
+
+	documentAILimiter.semaphore = make(chan struct{}, maxConcurrency)
+	documentAILimiter.reject = os.Getenv("CONCURRENCY_MODE") == "reject"
+	documentAILimiter.queueTimeout = durationFromEnv("CONCURRENCY_QUEUE_TIMEOUT", 30*time.Second)
+
+	mode := "queue"
+	if documentAILimiter.reject {
+		mode = "reject"
+	}
+	logInfof("Limiting Document AI concurrency to %d (mode: %s)", maxConcurrency, mode)
+}
+
+// acquireDocumentAISlot reserves a slot for a Document AI call. The returned
+// release function must be called once the call completes.
+func acquireDocumentAISlot(ctx context.Context) (func(), error) {
+	semaphore := documentAILimiter.semaphore
+	if semaphore == nil {
+		return func() {}, nil
+	}
+	release := func() { <-semaphore }
+
+	if documentAILimiter.reject {
+		select {
+		case semaphore <- struct{}{}:
+			return release, nil
+		default:
+			return nil, errBackendBusy
+		}
+	}
+
+	timer := time.NewTimer(documentAILimiter.queueTimeout)
+	defer timer.Stop()
+	select {
+	case semaphore <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, errBackendBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
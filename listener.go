@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/netutil"
+)
+
+// connectionLimitLogInterval keeps a sustained flood from logging the limit
+// on every connection.
+const connectionLimitLogInterval = time.Minute
+
+// limitedListener caps open connections with netutil.LimitListener, so once
+// MAX_CONNECTIONS are open further connections wait in the kernel's accept
+// queue instead of each getting a goroutine. It logs when the cap is
+// reached.
+type limitedListener struct {
+	net.Listener
+	max     int64
+	open    atomic.Int64
+	lastLog atomic.Int64
+}
+
+// listen opens the server's listener, limited when MAX_CONNECTIONS is set.
+func listen(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	maxConnections := intFromEnv("MAX_CONNECTIONS", 0)
+	if maxConnections == 0 {
+		return listener, nil
+	}
+	logInfof("Limiting the server to %d concurrent connections", maxConnections)
+	return &limitedListener{Listener: netutil.LimitListener(listener, maxConnections), max: int64(maxConnections)}, nil
+}
+
+func (l *limitedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if l.open.Add(1) >= l.max {
+		now := time.Now().UnixNano()
+		last := l.lastLog.Load()
+		if now-last >= int64(connectionLimitLogInterval) && l.lastLog.CompareAndSwap(last, now) {
+			logWarnf("Reached MAX_CONNECTIONS (%d), new connections wait until one closes", l.max)
+		}
+	}
+	return &countedConn{Conn: conn, listener: l}, nil
+}
+
+// countedConn releases its slot in the open count once, however often it is
+// closed.
+type countedConn struct {
+	net.Conn
+	listener *limitedListener
+	once     sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() { c.listener.open.Add(-1) })
+	return c.Conn.Close()
+}
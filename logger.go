@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = map[string]logLevel{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+var currentLogLevel = levelInfo
+
+// configureLogLevel reads LOG_LEVEL (debug, info, warn or error). The older
+// DEBUG=true switch maps to the debug level when LOG_LEVEL isn't set.
+func configureLogLevel() {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_LEVEL")))
+	if name == "" && os.Getenv("DEBUG") == "true" {
+		name = "debug"
+	}
+	if name == "" {
+		return
+	}
+
+	level, ok := logLevelNames[name]
+	if !ok {
+		logWarnf("Unknown LOG_LEVEL %q, using info", name)
+		return
+	}
+	currentLogLevel = level
+}
+
+func logDebugf(format string, args ...interface{}) {
+	if currentLogLevel <= levelDebug {
+		log.Printf("DEBUG: "+format, args...)
+	}
+}
+
+func logInfof(format string, args ...interface{}) {
+	if currentLogLevel <= levelInfo {
+		log.Printf(format, args...)
+	}
+}
+
+func logWarnf(format string, args ...interface{}) {
+	if currentLogLevel <= levelWarn {
+		log.Printf("WARN: "+format, args...)
+	}
+}
+
+func logErrorf(format string, args ...interface{}) {
+	log.Printf("ERROR: "+format, args...)
+}
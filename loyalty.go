@@ -0,0 +1,95 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// loyaltyLabelRegex matches the word that introduces a card or membership
+// number ("Karta Moja Biedronka", "Nr karty", "Member ID", "Club card").
+var loyaltyLabelRegex = regexp.MustCompile(`(?i)(?:^|[^\pL])(?:kart[ay]|card|member(?:ship)?|klub|club)(?:[^\pL]|$)`)
+
+// loyaltyNumberRegex matches a run of digits and mask characters, possibly
+// grouped with spaces or dashes, as in "2980 0123 4567" or "********1234".
+var loyaltyNumberRegex = regexp.MustCompile(`[0-9*Xx•](?:[0-9*Xx• -]*[0-9*Xx•])?`)
+
+// loyaltyQualifiers mark a card line as a loyalty card even when its number
+// is masked.
+var loyaltyQualifiers = []string{"klienta", "stałego", "stalego", "lojalnościowa", "lojalnosciowa", "loyalty", "member", "membership", "club", "klub", "rewards", "punkty", "points"}
+
+// paymentCardWords mark a line as being about the payment card, which must
+// never be taken for the loyalty card.
+var paymentCardWords = []string{
+	"visa", "mastercard", "maestro", "amex", "debit", "credit", "payment", "contactless", "terminal", "auth", "pin", "chip",
+	"płatnicza", "platnicza", "kredytowa", "debetowa", "płatność", "platnosc", "zapłacono", "zaplacono", "zbliżeniowa", "zblizeniowa", "autoryzacja",
+}
+
+// extractLoyaltyCard returns the loyalty or member card number printed
+// after a "Karta", "Card" or "Member" label, masked down to its last four
+// digits, or an empty string.
+//
+// Payment cards are printed with the same labels, so lines naming a card
+// scheme or payment, or carrying an amount ("KARTA 45,99" is the amount paid
+// by card), are skipped. Card numbers without a loyalty word such as
+// "klienta" or "member" are only taken when printed in full, since payment
+// card numbers are always masked on receipts.
+func extractLoyaltyCard(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		label := loyaltyLabelRegex.FindStringIndex(line)
+		if label == nil || isPaymentCardLine(line) {
+			continue
+		}
+		number, masked := loyaltyNumber(line[label[1]:])
+		// Allow the number on the line below a label that has none
+		if number == "" && i+1 < len(lines) && !isPaymentCardLine(lines[i+1]) && !strings.ContainsFunc(removeLoyaltyNumbers(lines[i+1]), unicode.IsLetter) {
+			number, masked = loyaltyNumber(lines[i+1])
+		}
+		if number == "" || (masked && !containsAnyWord(line, loyaltyQualifiers)) {
+			continue
+		}
+		return maskCardNumber(number)
+	}
+	return ""
+}
+
+func isPaymentCardLine(line string) bool {
+	return containsAnyWord(line, paymentCardWords) || len(findAmounts(line)) > 0
+}
+
+// loyaltyNumber returns the first plausible card number in s, with grouping
+// removed and mask characters turned into "*", and whether it was masked.
+// Short runs such as a "3" in "Club 3" are not card numbers.
+func loyaltyNumber(s string) (string, bool) {
+	for _, candidate := range loyaltyNumberRegex.FindAllString(s, -1) {
+		var b strings.Builder
+		digits := 0
+		masked := false
+		for _, r := range candidate {
+			switch {
+			case r >= '0' && r <= '9':
+				b.WriteRune(r)
+				digits++
+			case r == ' ' || r == '-':
+			default:
+				b.WriteByte('*')
+				masked = true
+			}
+		}
+		number := b.String()
+		if digits >= 4 && len(number) >= 6 && strings.Trim(number[len(number)-4:], "0123456789") == "" {
+			return number, masked
+		}
+	}
+	return "", false
+}
+
+func removeLoyaltyNumbers(line string) string {
+	return loyaltyNumberRegex.ReplaceAllString(line, "")
+}
+
+// maskCardNumber replaces all but the last four digits of number with "*".
+func maskCardNumber(number string) string {
+	return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestExtractLoyaltyCard(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"masked loyalty card", "Karta klienta ****1234", "****1234"},
+		{"amount paid by card", "KARTA 45,99", ""},
+		{"masked payment card", "VISA ************1234", ""},
+		{"number after bare label", "Karta Moja Biedronka\n2980 0123 4567", "********4567"},
+		{"full number without qualifier", "Karta 2980012345671234", "************1234"},
+		{"masked number without qualifier", "Karta ****1234", ""},
+		{"short number", "Club 3", ""},
+		{"no label", "MLEKO 3,99\nSUMA PLN 3,99", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractLoyaltyCard(tt.text); got != tt.want {
+				t.Errorf("extractLoyaltyCard(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
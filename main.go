@@ -2,34 +2,103 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
+	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
-	documentai "cloud.google.com/go/documentai/apiv1"
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
 	"github.com/joho/godotenv"
-	documentaipb "google.golang.org/genproto/googleapis/cloud/documentai/v1"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type OCRRequest struct {
-	ImageURL     string `json:"image_url,omitempty"`
-	Base64Image  string `json:"base64_image,omitempty"`
-	Instructions string `json:"instructions,omitempty"`
+	ImageURL                  string   `json:"image_url,omitempty"`
+	Base64Image               string   `json:"base64_image,omitempty"`
+	DataURI                   string   `json:"data_uri,omitempty"`
+	Instructions              string   `json:"instructions,omitempty"`
+	Mode                      string   `json:"mode,omitempty"`
+	LanguageHints             []string `json:"language_hints,omitempty"`
+	Locale                    string   `json:"locale,omitempty"`
+	MaxDimension              int      `json:"max_dimension,omitempty"`
+	PreprocessFilters         []string `json:"preprocess_filters,omitempty"`
+	Pages                     string   `json:"pages,omitempty"`
+	Debug                     bool     `json:"debug,omitempty"`
+	IncludeBlocks             bool     `json:"include_blocks,omitempty"`
+	IncludeWordConfidence     bool     `json:"include_word_confidence,omitempty"`
+	IncludePropertyConfidence bool     `json:"include_property_confidence,omitempty"`
+	SortItems                 string   `json:"sort_items,omitempty"`
+	ExpectedCurrency          string   `json:"expected_currency,omitempty"`
+	// RequireConfidence drops the merchant, date and total when their
+	// entity's confidence is below it
+	RequireConfidence float32 `json:"require_confidence,omitempty"`
+	TimeoutSeconds    int     `json:"timeout_seconds,omitempty"`
+	// Async returns a job ID straight away, see handleJob
+	Async bool `json:"async,omitempty"`
+	// Images replaces the single image source with the photos of a receipt
+	// split across several pictures, see processStitched
+	Images []ImageSource `json:"images,omitempty"`
+	// DownloadHeaders are sent when fetching image_url, see
+	// validateDownloadHeaders
+	DownloadHeaders map[string]string `json:"download_headers,omitempty"`
+	// IncludeFullText defaults to true; false leaves text out of the response
+	IncludeFullText *bool `json:"include_full_text,omitempty"`
+	// content is image data the server already has, such as an archive entry
+	content []byte
+}
+
+func (req OCRRequest) wantsFullText() bool {
+	return req.IncludeFullText == nil || *req.IncludeFullText
+}
+
+// Processing modes. Text mode returns only the OCR text and skips all
+// receipt parsing.
+const (
+	modeFull = "full"
+	modeText = "text"
+)
+
+type ParseRequest struct {
+	Text             string `json:"text"`
+	Instructions     string `json:"instructions,omitempty"`
+	ExpectedCurrency string `json:"expected_currency,omitempty"`
 }
 
 type OCRResponse struct {
-	Success bool     `json:"success"`
-	Text    []string `json:"text,omitempty"`
-	Error   string   `json:"error,omitempty"`
+	Success bool             `json:"success"`
+	Text    []string         `json:"text,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	Code    string           `json:"code,omitempty"`
+	Receipt *Receipt         `json:"receipt,omitempty"`
+	Debug   *DebugInfo       `json:"debug,omitempty"`
+	Blocks  []TextBlock      `json:"blocks,omitempty"`
+	Words   []WordConfidence `json:"words,omitempty"`
+
+	WordConfidence *WordConfidenceSummary `json:"word_confidence,omitempty"`
+	// NoTextDetected is set when Document AI found neither text nor
+	// entities, e.g. for a blank page or an unreadable photo
+	NoTextDetected bool `json:"no_text_detected,omitempty"`
+	// Partial is set when the soft deadline passed and the response covers
+	// only the part of the input processed by then
+	Partial bool `json:"partial,omitempty"`
 }
 
 type ReceiptField struct {
@@ -41,23 +110,113 @@ type ReceiptField struct {
 type ReceiptItem struct {
 	Description string `json:"description"`
 	Quantity    string `json:"quantity,omitempty"`
+	Unit        string `json:"unit,omitempty"`
 	Price       string `json:"price,omitempty"`
 	TotalPrice  string `json:"total_price,omitempty"`
+	Computed    bool   `json:"computed,omitempty"`
+	UnitPrice   string `json:"unit_price,omitempty"`
+	ProductCode string `json:"product_code,omitempty"`
+	// TaxRate is the VAT rate in percent from the letter printed after the
+	// price, for items read from the text
+	TaxRate *float64 `json:"tax_rate,omitempty"`
+	// RawDescription keeps the description as printed when a leading item
+	// code was stripped from it
+	RawDescription string `json:"raw_description,omitempty"`
+	// Extra holds line item properties without a dedicated field
+	Extra      map[string]string `json:"extra,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	Confidence float32           `json:"confidence,omitempty"`
+	// PropertyConfidence is only returned when include_property_confidence is set
+	PropertyConfidence map[string]float32 `json:"property_confidence,omitempty"`
+	ID                 string             `json:"id,omitempty"`
+	// Index is the item's 1-based position in reading order and Page the
+	// 1-based page it was read from
+	Index int `json:"index,omitempty"`
+	Page  int `json:"page,omitempty"`
+
+	// offset is where the item's text starts in the document, used to
+	// order items for Index
+	offset int64
 }
 
+type DetectedLanguage struct {
+	Code       string  `json:"code"`
+	Confidence float32 `json:"confidence"`
+}
+
+// Field sources, reported so clients can trust Document AI entities more
+// than the heuristic text fallback.
+const (
+	sourceDocumentAI   = "documentai"
+	sourceTextFallback = "text_fallback"
+	sourceEXIF         = "exif"
+)
+
 type Receipt struct {
-	MerchantName string         `json:"merchant_name,omitempty"`
-	Date         string         `json:"date,omitempty"`
-	TotalAmount  string         `json:"total_amount,omitempty"`
-	Items        []ReceiptItem  `json:"items,omitempty"`
-	Fields       []ReceiptField `json:"fields,omitempty"`
+	MerchantName           string `json:"merchant_name,omitempty"`
+	MerchantNameSource     string `json:"merchant_name_source,omitempty"`
+	MerchantNameNormalized string `json:"merchant_name_normalized,omitempty"`
+	// VendorID is the known vendor the merchant name matched, with
+	// MatchScore its similarity from 0 to 1
+	VendorID           string             `json:"vendor_id,omitempty"`
+	MatchScore         float64            `json:"match_score,omitempty"`
+	MerchantAddress    string             `json:"merchant_address,omitempty"`
+	MerchantPhone      string             `json:"merchant_phone,omitempty"`
+	TransactionNumber  string             `json:"transaction_number,omitempty"`
+	Cashier            string             `json:"cashier,omitempty"`
+	FiscalNumber       string             `json:"fiscal_number,omitempty"`
+	LoyaltyCard        string             `json:"loyalty_card,omitempty"`
+	Barcodes           []Barcode          `json:"barcodes,omitempty"`
+	Date               string             `json:"date,omitempty"`
+	NormalizedDate     string             `json:"normalized_date,omitempty"`
+	NormalizedTime     string             `json:"normalized_time,omitempty"`
+	DateSource         string             `json:"date_source,omitempty"`
+	ExifTimestamp      string             `json:"exif_timestamp,omitempty"`
+	TotalAmount        string             `json:"total_amount,omitempty"`
+	TotalAmountSource  string             `json:"total_amount_source,omitempty"`
+	TotalAmountValue   float64            `json:"total_amount_value,omitempty"`
+	IsRefund           bool               `json:"is_refund,omitempty"`
+	FormattedTotal     string             `json:"formatted_total,omitempty"`
+	Currency           string             `json:"currency,omitempty"`
+	CurrencySource     string             `json:"currency_source,omitempty"`
+	ImageHash          string             `json:"image_hash,omitempty"`
+	DuplicateSuspected bool               `json:"duplicate_suspected,omitempty"`
+	DetectedLanguages  []DetectedLanguage `json:"detected_languages,omitempty"`
+	PageOrientations   []PageOrientation  `json:"page_orientations,omitempty"`
+	Subtotal           float64            `json:"subtotal,omitempty"`
+	Tax                float64            `json:"tax,omitempty"`
+	Tip                float64            `json:"tip,omitempty"`
+	// TotalAmountMinor and the other *Minor fields repeat the numeric money
+	// fields as integer counts of the currency's minor unit, e.g. cents
+	TotalAmountMinor int64 `json:"total_amount_minor,omitempty"`
+	SubtotalMinor    int64 `json:"subtotal_minor,omitempty"`
+	TaxMinor         int64 `json:"tax_minor,omitempty"`
+	TipMinor         int64 `json:"tip_minor,omitempty"`
+	// TotalsReconcile is only set when both a subtotal and a total were found
+	TotalsReconcile   *bool   `json:"totals_reconcile,omitempty"`
+	TotalsDiscrepancy float64 `json:"totals_discrepancy,omitempty"`
+	ItemCount         int     `json:"item_count"`
+	// FilteredItemCount counts text fallback items dropped for an
+	// implausible price
+	FilteredItemCount int `json:"filtered_item_count,omitempty"`
+	// Truncated is set when more than MAX_ITEMS items were found and the
+	// rest were dropped
+	Truncated          bool    `json:"truncated,omitempty"`
+	ItemsPriceSum      float64 `json:"items_price_sum,omitempty"`
+	ItemsPriceSumMinor int64   `json:"items_price_sum_minor,omitempty"`
+	// ItemsDiscrepancy is the total minus ItemsPriceSum, when they differ
+	ItemsDiscrepancy float64        `json:"items_discrepancy,omitempty"`
+	Items            []ReceiptItem  `json:"items,omitempty"`
+	Fields           []ReceiptField `json:"fields,omitempty"`
+	// LowConfidenceFields lists fields cleared by require_confidence
+	LowConfidenceFields []string `json:"low_confidence_fields,omitempty"`
 }
 
 func testGoogleCloudConnection() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := documentai.NewDocumentProcessorClient(ctx)
+	client, err := newDocumentAIClient(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create Document AI client: %v", err)
 	}
@@ -83,8 +242,10 @@ func testGoogleCloudConnection() error {
 }
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
+	envErr := godotenv.Load()
+	configureLogLevel()
+	if envErr != nil {
+		logInfof("No .env file found, using environment variables")
 	}
 
 	requiredEnvVars := []string{
@@ -94,48 +255,119 @@ func main() {
 		"DOCUMENT_AI_PROCESSOR_ID",
 	}
 
-	for _, envVar := range requiredEnvVars {
-		if os.Getenv(envVar) == "" {
-			log.Printf("ERROR: Required environment variable %s is not set", envVar)
-			os.Exit(1)
+	// Canned responses need no Google Cloud configuration, so the service
+	// can run offline in CI and local development
+	fakeBackend := os.Getenv("DOCUMENT_AI_FAKE_RESPONSE") != "" || os.Getenv("DOCUMENT_AI_FAKE_ERROR") != ""
+	if !fakeBackend {
+		for _, envVar := range requiredEnvVars {
+			if os.Getenv(envVar) == "" {
+				logErrorf("Required environment variable %s is not set", envVar)
+				os.Exit(1)
+			}
 		}
 	}
 
 	skipGoogleCloud := false
 
-	if !skipGoogleCloud {
+	if fakeBackend {
+		if _, err := loadFakeDocumentProcessor(); err != nil {
+			logErrorf("%v", err)
+			os.Exit(1)
+		}
+		logWarnf("Serving canned Document AI responses, no documents are sent to Google Cloud")
+	} else if !skipGoogleCloud {
 		credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
-		log.Printf("Using Google Cloud credentials from: %s", credentialsPath)
+		logInfof("Using Google Cloud credentials from: %s", credentialsPath)
 		if _, err := os.Stat(credentialsPath); os.IsNotExist(err) {
-			log.Printf("ERROR: Google Cloud credentials file not found at %s", credentialsPath)
+			logErrorf("Google Cloud credentials file not found at %s", credentialsPath)
 			os.Exit(1)
 		} else {
-			log.Println("Google Cloud credentials file exists")
+			logDebugf("Google Cloud credentials file exists")
+		}
+
+		endpoint, err := documentAIEndpoint()
+		if err != nil {
+			logErrorf("Invalid Document AI endpoint configuration: %v", err)
+			os.Exit(1)
 		}
+		logInfof("Using Document AI endpoint: %s", endpoint)
 
-		log.Println("Testing connection to Google Cloud Document AI...")
+		logInfof("Testing connection to Google Cloud Document AI...")
 		if err := testGoogleCloudConnection(); err != nil {
-			log.Printf("ERROR: Failed to connect to Google Cloud Document AI: %v", err)
+			logErrorf("Failed to connect to Google Cloud Document AI: %v", err)
 			os.Exit(1)
 		}
-		log.Println("Successfully connected to Google Cloud Document AI")
+		logInfof("Successfully connected to Google Cloud Document AI")
 	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
-	log.Printf("Using port: %s", port)
+	logDebugf("Using port: %s", port)
+
+	if currentLogLevel == levelDebug {
+		logInfof("Debug logging enabled")
+	}
+
+	configureConcurrencyLimit()
+	configureDuplicateDetection()
+	configureCircuitBreaker()
+	configureClientWatchdog()
+	configureMerchantNoiseTokens()
+
+	if defaultCurrency := os.Getenv("DEFAULT_CURRENCY"); defaultCurrency != "" && normalizeCurrencyCode(defaultCurrency) == "" {
+		logErrorf("DEFAULT_CURRENCY %q is not a valid ISO 4217 currency code", defaultCurrency)
+		os.Exit(1)
+	}
+
+	if err := loadProcessorMap(); err != nil {
+		logErrorf("%v", err)
+		os.Exit(1)
+	}
+
+	if err := loadVATRates(); err != nil {
+		logErrorf("%v", err)
+		os.Exit(1)
+	}
+
+	if err := configureMonitoringAllowlist(); err != nil {
+		logErrorf("%v", err)
+		os.Exit(1)
+	}
+
+	if err := configureImageTLS(); err != nil {
+		logErrorf("%v", err)
+		os.Exit(1)
+	}
 
-	debug := os.Getenv("DEBUG") == "true"
-	if debug {
-		log.Println("Debug mode enabled")
+	if err := configureJobs(); err != nil {
+		logErrorf("%v", err)
+		os.Exit(1)
+	}
+
+	if err := configureVendors(); err != nil {
+		logErrorf("%v", err)
+		os.Exit(1)
 	}
 
-	log.Println("Registering HTTP handlers...")
+	if err := configureContentTypes(); err != nil {
+		logErrorf("%v", err)
+		os.Exit(1)
+	}
+
+	logDebugf("Registering HTTP handlers...")
 	http.HandleFunc("/health", handleHealth)
+	http.HandleFunc("/ready", handleReady)
+	http.HandleFunc("/version", handleVersion)
+	http.HandleFunc("/api/parse", withAPIKey(withSignature(handleParse), false))
 	if !skipGoogleCloud {
-		http.HandleFunc("/api/ocr", handleOCR)
+		http.HandleFunc("/api/ocr", withAPIKey(withSignature(handleOCR), false))
+		http.HandleFunc("/api/ocr/archive", withAPIKey(withSignature(handleArchive), false))
+		http.HandleFunc("/api/selftest", withMonitoringAccess(handleSelfTest, true))
+		http.HandleFunc("/api/jobs/{id}", withAPIKey(handleJob, false))
+		http.HandleFunc("/api/schema", withMonitoringAccess(handleSchema, false))
+		http.HandleFunc("/api/events/gcs", withPushAuth(handleGCSEvent))
 	} else {
 		// Add a simple handler for /api/ocr that doesn't use Google Cloud
 		http.HandleFunc("/api/ocr", func(w http.ResponseWriter, r *http.Request) {
@@ -143,19 +375,52 @@ func main() {
 			json.NewEncoder(w).Encode(map[string]string{"status": "Google Cloud Document AI is disabled"})
 		})
 	}
-	log.Println("HTTP handlers registered successfully")
+	logDebugf("HTTP handlers registered successfully")
 
-	log.Printf("OCR Service starting on port %s...\n", port)
+	logInfof("OCR Service %s (commit %s, built %s) starting on port %s...", version, gitCommit, buildTime, port)
 
 	server := &http.Server{
-		Addr:         ":" + port,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		Addr:        ":" + port,
+		Handler:     withRequestID(withRecovery(http.DefaultServeMux)),
+		ReadTimeout: 10 * time.Second,
+		// Leave room for the longest Document AI call a client may request
+		WriteTimeout: durationFromEnv("MAX_TIMEOUT", 120*time.Second) + 10*time.Second,
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
 	}
 
-	log.Printf("Starting HTTP server on port %s...", port)
-	if err := server.ListenAndServe(); err != nil {
-		log.Printf("ERROR: Server failed: %v", err)
+	certFile := os.Getenv("TLS_CERT_FILE")
+	keyFile := os.Getenv("TLS_KEY_FILE")
+	if (certFile == "") != (keyFile == "") {
+		logErrorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+		os.Exit(1)
+	}
+
+	listener, err := listen(server.Addr)
+	if err != nil {
+		logErrorf("Failed to listen on port %s: %v", port, err)
+		os.Exit(1)
+	}
+
+	if certFile != "" {
+		if os.Getenv("DISABLE_HTTP2") == "true" {
+			// A non-nil, empty map stops net/http from negotiating h2
+			server.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+			logInfof("HTTP/2 disabled")
+		}
+
+		logInfof("Starting HTTPS server on port %s...", port)
+		if err := server.ServeTLS(listener, certFile, keyFile); err != nil {
+			logErrorf("Server failed: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	logInfof("Starting HTTP server on port %s...", port)
+	if err := server.Serve(listener); err != nil {
+		logErrorf("Server failed: %v", err)
 		os.Exit(1)
 	}
 }
@@ -166,102 +431,458 @@ func handleHealth(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleOCR(w http.ResponseWriter, r *http.Request) {
+	// REQUEST_TIMEOUT counts from here, so it covers reading the request too
+	start := time.Now()
 	if r.Method != http.MethodPost {
 		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req OCRRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendErrorResponse(w, "Invalid request format", http.StatusBadRequest)
+	version, err := negotiateResponseVersion(r)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusNotAcceptable)
 		return
 	}
-
-	ctx := context.Background()
-	texts, receipt, err := processDocument(ctx, req)
+	if err := validateResponseFormat(r); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	soft, err := softDeadline(r)
 	if err != nil {
-		sendErrorResponse(w, fmt.Sprintf("Error processing document: %v", err), http.StatusInternalServerError)
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	response := OCRResponse{
-		Success: true,
-		Text:    texts,
+	// Large base64 uploads are spooled like downloads, and decoding from the
+	// exact-size body avoids the decoder's buffer growth
+	maxBytes := maxRequestBytes()
+	body, err := readBody(r.Body, maxBytes)
+	if errors.Is(err, errDocumentTooLarge) {
+		sendErrorResponse(w, fmt.Sprintf("Request body exceeds %d bytes", maxBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var req OCRRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		sendErrorResponse(w, "Invalid request format", http.StatusBadRequest)
+		return
 	}
 
-	// If we have receipt data, include it in the response
-	if receipt != nil {
-		// Add receipt data to the response
-		responseBytes, err := json.Marshal(response)
-		if err != nil {
-			sendErrorResponse(w, fmt.Sprintf("Error serializing response: %v", err), http.StatusInternalServerError)
+	if err := validateImageSource(req); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Instructions == "" {
+		req.Instructions = os.Getenv("DEFAULT_INSTRUCTIONS")
+	}
+	if err := validateDownloadHeaders(req.DownloadHeaders); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validatePreprocessFilters(req.PreprocessFilters); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.LanguageHints) == 0 {
+		req.LanguageHints = defaultLanguageHints()
+	}
+	if err := validateLanguageHints(req.LanguageHints); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Pages != "" {
+		if _, err := parsePageSelection(req.Pages); err != nil {
+			sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Locale != "" {
+		if _, err := language.Parse(req.Locale); err != nil {
+			sendErrorResponse(w, fmt.Sprintf("invalid locale %q", req.Locale), http.StatusBadRequest)
 			return
 		}
+	}
 
-		var responseMap map[string]interface{}
-		if err := json.Unmarshal(responseBytes, &responseMap); err != nil {
-			sendErrorResponse(w, fmt.Sprintf("Error processing response: %v", err), http.StatusInternalServerError)
+	if req.RequireConfidence < 0 || req.RequireConfidence > 1 {
+		sendErrorResponse(w, "require_confidence must be between 0 and 1", http.StatusBadRequest)
+		return
+	}
+	if req.ExpectedCurrency != "" {
+		code := normalizeCurrencyCode(req.ExpectedCurrency)
+		if code == "" {
+			sendErrorResponse(w, fmt.Sprintf("invalid expected_currency %q", req.ExpectedCurrency), http.StatusBadRequest)
 			return
 		}
+		req.ExpectedCurrency = code
+	}
+
+	switch req.Mode {
+	case "", modeFull, modeText:
+	default:
+		sendErrorResponse(w, fmt.Sprintf("invalid mode %q: must be full or text", req.Mode), http.StatusBadRequest)
+		return
+	}
 
-		receiptBytes, err := json.Marshal(receipt)
+	switch req.SortItems {
+	case "", "price_desc", "price_asc", "name":
+	default:
+		sendErrorResponse(w, fmt.Sprintf("invalid sort_items %q: must be price_desc, price_asc or name", req.SortItems), http.StatusBadRequest)
+		return
+	}
+
+	if req.Async {
+		id, err := startJob(r.Context(), req)
 		if err != nil {
-			sendErrorResponse(w, fmt.Sprintf("Error serializing receipt: %v", err), http.StatusInternalServerError)
+			sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		sendJobStatus(w, r, id, jobProcessing)
+		return
+	}
+
+	ctx := r.Context()
+	if timeout := durationFromEnv("REQUEST_TIMEOUT", 0); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout-time.Since(start), errRequestTimeout)
+		defer cancel()
+	}
+	// Only stitched requests have parts to return early; a single image is
+	// one Document AI call with nothing to show until it returns, so the
+	// soft deadline is ignored for it
+	if soft > 0 && len(req.Images) > 0 {
+		ctx = withSoftDeadline(ctx, start.Add(soft))
+	} else if soft > 0 {
+		logDebugf("Ignoring soft deadline for single-image request")
+	}
+	result, err := processDocument(ctx, req)
+	// Steps that don't watch the context, such as downscaling, can finish
+	// after the deadline, so check it even when processing succeeded
+	if errors.Is(context.Cause(ctx), errRequestTimeout) {
+		logWarnf("Request exceeded REQUEST_TIMEOUT")
+		err = errRequestTimeout
+	}
+	if err != nil {
+		sendProcessingError(w, err)
+		return
+	}
+
+	if persistResults() {
+		id := newJobID()
+		if err := results.Save(r.Context(), id, StoredResult{Status: jobCompleted, Result: result}, jobResultTTL); err != nil {
+			logErrorf("Failed to save result %s: %v", id, err)
+		} else {
+			w.Header().Set("X-Job-ID", id)
+		}
+	}
+
+	sendOCRResponse(w, r, result, version)
+}
+
+// errorOutcome is the error response for a failed processDocument call,
+// kept as data so async jobs can return it later.
+type errorOutcome struct {
+	Message    string `json:"message"`
+	Code       string `json:"code,omitempty"`
+	Status     int    `json:"status"`
+	RetryAfter int    `json:"retry_after,omitempty"`
+}
+
+func newErrorOutcome(err error) *errorOutcome {
+	var quotaErr *quotaExceededError
+	if errors.As(err, &quotaErr) {
+		return &errorOutcome{
+			Message:    err.Error(),
+			Code:       errorCodeQuotaExceeded,
+			Status:     http.StatusTooManyRequests,
+			RetryAfter: int(quotaErr.retryAfter.Seconds()),
+		}
+	}
+
+	status := errorStatus(err)
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		message = fmt.Sprintf("Error processing document: %v", err)
+	}
+	return &errorOutcome{Message: message, Status: status}
+}
+
+func sendErrorOutcome(w http.ResponseWriter, outcome *errorOutcome) {
+	if outcome.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(outcome.RetryAfter))
+	}
+	sendErrorResponseWithCode(w, outcome.Message, outcome.Code, outcome.Status)
+}
+
+// sendProcessingError maps an error from processDocument to its response.
+func sendProcessingError(w http.ResponseWriter, err error) {
+	sendErrorOutcome(w, newErrorOutcome(err))
+}
+
+func handleParse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	version, err := negotiateResponseVersion(r)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	if err := validateResponseFormat(r); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req ParseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		sendErrorResponse(w, "No text provided", http.StatusBadRequest)
+		return
+	}
+	if req.Instructions == "" {
+		req.Instructions = os.Getenv("DEFAULT_INSTRUCTIONS")
+	}
 
-		var receiptMap map[string]interface{}
-		if err := json.Unmarshal(receiptBytes, &receiptMap); err != nil {
-			sendErrorResponse(w, fmt.Sprintf("Error processing receipt: %v", err), http.StatusInternalServerError)
+	if req.ExpectedCurrency != "" {
+		code := normalizeCurrencyCode(req.ExpectedCurrency)
+		if code == "" {
+			sendErrorResponse(w, fmt.Sprintf("invalid expected_currency %q", req.ExpectedCurrency), http.StatusBadRequest)
 			return
 		}
+		req.ExpectedCurrency = code
+	}
+
+	// Run the same extraction used for OCR results, without any entities,
+	// so only the text-based parsing applies
+	texts, receipt := extractDataFromDocument(&documentaipb.Document{Text: req.Text}, req.Instructions, req.ExpectedCurrency)
+	textHash := sha256.Sum256([]byte(req.Text))
+	assignItemIDs(receipt.Items, hex.EncodeToString(textHash[:]))
+
+	sendOCRResponse(w, r, &ocrResult{Texts: texts, Receipt: receipt}, version)
+}
+
+// parseFieldsParam reads the comma-separated ?fields= query parameter. Names
+// that don't match a response field are ignored.
+func parseFieldsParam(r *http.Request) map[string]bool {
+	param := r.URL.Query().Get("fields")
+	if param == "" {
+		return nil
+	}
+
+	known := map[string]bool{"text": true, "blocks": true, "words": true, "word_confidence": true}
+	receiptType := reflect.TypeOf(Receipt{})
+	for i := 0; i < receiptType.NumField(); i++ {
+		name := strings.Split(receiptType.Field(i).Tag.Get("json"), ",")[0]
+		known[name] = true
+	}
+
+	fields := make(map[string]bool)
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if known[name] {
+			fields[name] = true
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// pruneReceipt returns a copy of receipt with every field not listed in
+// fields zeroed, so omitempty drops it from the JSON output.
+func pruneReceipt(receipt *Receipt, fields map[string]bool) *Receipt {
+	pruned := *receipt
+	value := reflect.ValueOf(&pruned).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+		if !fields[name] {
+			value.Field(i).Set(reflect.Zero(value.Field(i).Type()))
+		}
+	}
+	return &pruned
+}
+
+func sendOCRResponse(w http.ResponseWriter, r *http.Request, result *ocrResult, version int) {
+	response := OCRResponse{
+		Success: true,
+		Text:    result.Texts,
+		Receipt: result.Receipt,
+		Debug:   result.Debug,
+		Blocks:  result.Blocks,
+		Words:   result.Words,
+
+		WordConfidence: result.WordConfidence,
+		NoTextDetected: result.NoTextDetected,
+		Partial:        result.Partial,
+	}
+
+	if fields := parseFieldsParam(r); fields != nil {
+		if !fields["text"] {
+			response.Text = nil
+		}
+		if !fields["blocks"] {
+			response.Blocks = nil
+		}
+		if !fields["words"] {
+			response.Words = nil
+		}
+		if !fields["word_confidence"] {
+			response.WordConfidence = nil
+		}
+		if result.Receipt != nil {
+			response.Receipt = pruneReceipt(result.Receipt, fields)
+		}
+	}
+
+	if result.Partial {
+		w.Header().Set(partialResultHeader, "true")
+	}
+	// Every encoding says which schema version it was negotiated for
+	w.Header().Set("X-Response-Version", strconv.Itoa(version))
+
+	if r.URL.Query().Get("format") == formatFlat {
+		sendFlatResponse(w, result.Receipt)
+		return
+	}
 
-		responseMap["receipt"] = receiptMap
+	w.Header().Add("Vary", "Accept")
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(responseMap)
+	if wantsProtobuf(r) {
+		body, err := marshalProtobufResponse(response)
+		if err != nil {
+			sendErrorResponse(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", protobufContentType)
+		if _, err := w.Write(body); err != nil {
+			logErrorf("Failed to write response: %v", err)
+		}
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+
+	// Encode straight to the connection so large document texts aren't
+	// copied into intermediate buffers, unless keys have to be renamed
+	var body interface{} = response
+	if version == responseV2 {
+		body = newOCRResponseV2(response)
+	}
+	if err := writeJSON(w, r, body); err != nil {
+		logErrorf("Failed to write response: %v", err)
+	}
 }
 
-func processDocument(ctx context.Context, req OCRRequest) ([]string, *Receipt, error) {
-	log.Println("Initializing Document AI client...")
-	client, err := documentai.NewDocumentProcessorClient(ctx)
+// ocrResult is everything processDocument produces for a single response.
+type ocrResult struct {
+	Texts   []string
+	Receipt *Receipt
+	Debug   *DebugInfo
+	Blocks  []TextBlock
+	Words   []WordConfidence
+
+	WordConfidence *WordConfidenceSummary
+	NoTextDetected bool
+	Partial        bool
+}
+
+func processDocument(ctx context.Context, req OCRRequest) (*ocrResult, error) {
+	if len(req.Images) > 0 {
+		return processStitched(ctx, req)
+	}
+
+	// The outcome is recorded with the holder the client came from, since
+	// the call may outlive this request
+	shared := documentAIClient
+	client, err := shared.Get()
 	if err != nil {
-		log.Printf("ERROR: Failed to create Document AI client: %v", err)
-		return nil, nil, fmt.Errorf("failed to create client: %v", err)
+		logErrorf("Failed to create Document AI client: %v", err)
+		return nil, fmt.Errorf("failed to create client: %v", err)
 	}
-	log.Println("Document AI client initialized successfully")
-	defer client.Close()
 
 	// Get image bytes
 	var imageBytes []byte
-	if req.ImageURL != "" {
-		log.Printf("Processing image from URL: %s", req.ImageURL)
-		imageBytes, err = downloadImage(req.ImageURL)
+	var declaredType string
+	if req.content != nil {
+		imageBytes = req.content
+	} else if req.DataURI != "" {
+		logDebugf("Processing image from data URI")
+		imageBytes, declaredType, err = parseDataURI(req.DataURI)
+		if err != nil {
+			return nil, newClientError("failed to parse data URI: %w", err)
+		}
+	} else if req.ImageURL != "" {
+		logDebugf("Processing image from URL: %s", req.ImageURL)
+		imageBytes, err = downloadImage(ctx, req.ImageURL, req.DownloadHeaders)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to download image: %v", err)
+			return nil, newClientError("failed to download image: %w", err)
 		}
 	} else if req.Base64Image != "" {
+		// Check the size before decoding rather than allocate for it
+		if maxBytes := maxDocumentBytes(); int64(base64.StdEncoding.DecodedLen(len(req.Base64Image))) > maxBytes+2 {
+			return nil, bodyTooLarge(maxBytes)
+		}
 		imageBytes, err = base64.StdEncoding.DecodeString(req.Base64Image)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to decode base64 image: %v", err)
+			return nil, newClientError("failed to decode base64 image: %v", err)
 		}
 	} else {
-		return nil, nil, fmt.Errorf("no image provided")
+		return nil, newClientError("no image provided")
+	}
+	if maxBytes := maxDocumentBytes(); int64(len(imageBytes)) > maxBytes {
+		return nil, bodyTooLarge(maxBytes)
 	}
 
 	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
 	location := os.Getenv("DOCUMENT_AI_LOCATION")
-	processorID := os.Getenv("DOCUMENT_AI_PROCESSOR_ID")
+	processorID := selectProcessorID(req.Instructions)
+	logDebugf("Using Document AI processor: %s", processorID)
 
 	name := fmt.Sprintf("projects/%s/locations/%s/processors/%s", projectID, location, processorID)
-	mimeType := "image/jpeg"
-	if len(imageBytes) > 2 && imageBytes[0] == 0x89 && imageBytes[1] == 0x50 { // PNG signature
-		mimeType = "image/png"
+	imageBytes = trimLeadingNoise(imageBytes)
+	imageHash := sha256.Sum256(imageBytes)
+	mimeType, err := detectMimeType(imageBytes)
+	if err != nil {
+		return nil, err
+	}
+	if declaredType != "" && declaredType != mimeType {
+		logWarnf("data URI declares %s but content looks like %s, using %s", declaredType, mimeType, mimeType)
+	}
+	if err := checkContentType(mimeType); err != nil {
+		return nil, err
+	}
+	if err := validateImage(imageBytes, mimeType); err != nil {
+		return nil, err
+	}
+
+	// Read EXIF before any downscaling, since re-encoding drops it
+	var captureTime time.Time
+	var hasCaptureTime bool
+	if mimeType == "image/jpeg" {
+		captureTime, hasCaptureTime = exifCaptureTime(imageBytes)
+	}
+
+	maxDimension := req.MaxDimension
+	if maxDimension == 0 {
+		maxDimension, _ = strconv.Atoi(os.Getenv("MAX_IMAGE_DIMENSION"))
+	}
+	if maxDimension > 0 {
+		imageBytes, err = downscaleImage(imageBytes, mimeType, maxDimension)
+		if err != nil {
+			return nil, fmt.Errorf("failed to downscale image: %v", err)
+		}
+	}
+	imageBytes, err = preprocessImage(imageBytes, mimeType, req.PreprocessFilters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preprocess image: %v", err)
 	}
 
 	processRequest := &documentaipb.ProcessRequest{
@@ -273,52 +894,451 @@ func processDocument(ctx context.Context, req OCRRequest) ([]string, *Receipt, e
 			},
 		},
 	}
+	if len(req.LanguageHints) > 0 {
+		logDebugf("Processing with language hints: %v", req.LanguageHints)
+		processRequest.ProcessOptions = &documentaipb.ProcessOptions{
+			OcrConfig: &documentaipb.OcrConfig{
+				Hints: &documentaipb.OcrConfig_Hints{
+					LanguageHints: req.LanguageHints,
+				},
+			},
+		}
+	}
+	if req.Pages != "" {
+		// Already validated in handleOCR
+		pages, _ := parsePageSelection(req.Pages)
+		logDebugf("Processing pages: %v", pages)
+		if processRequest.ProcessOptions == nil {
+			processRequest.ProcessOptions = &documentaipb.ProcessOptions{}
+		}
+		processRequest.ProcessOptions.PageRange = &documentaipb.ProcessOptions_IndividualPageSelector_{
+			IndividualPageSelector: &documentaipb.ProcessOptions_IndividualPageSelector{Pages: pages},
+		}
+	}
+	if req.Mode == modeText {
+		paths := []string{"text"}
+		if req.IncludeBlocks || req.IncludeWordConfidence {
+			paths = append(paths, "pages")
+		}
+		processRequest.FieldMask = &fieldmaskpb.FieldMask{Paths: paths}
+	} else if paths := documentAIFieldMask(req.IncludeBlocks || req.IncludeWordConfidence); len(paths) > 0 {
+		processRequest.FieldMask = &fieldmaskpb.FieldMask{Paths: paths}
+	}
 	if req.Instructions != "" {
-		log.Printf("Processing with instructions: %s", req.Instructions)
+		logDebugf("Processing with instructions: %s", req.Instructions)
 	}
 
-	log.Println("Sending request to Document AI...")
-	response, err := client.ProcessDocument(ctx, processRequest)
-	if err != nil {
-		log.Printf("ERROR: Document AI request failed: %v", err)
-		return nil, nil, fmt.Errorf("failed to process document: %v", err)
-	}
-	log.Println("Received response from Document AI")
+	// Identical requests in flight at the same time share one call
+	key := coalescingKey(imageHash, fmt.Sprintf("%d %v", maxDimension, req.PreprocessFilters), processRequest)
+	response, err := documentAICalls.Do(ctx, key, func(ctx context.Context) (*documentaipb.ProcessResponse, error) {
+		release, err := acquireDocumentAISlot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
 
-	// Extract text and structured data from the response
-	texts, receipt := extractDataFromDocument(response.Document, req.Instructions)
+		timeout := documentAITimeout(req.TimeoutSeconds)
+		processCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
 
-	return texts, receipt, nil
-}
+		if !documentAIBreaker.Allow() {
+			return nil, errCircuitOpen
+		}
 
-func downloadImage(url string) ([]byte, error) {
-	resp, err := http.Get(url)
-	if err != nil {
+		logDebugf("Sending request to Document AI (timeout %s)...", timeout)
+		response, err := client.ProcessDocument(processCtx, processRequest, documentAIRetry())
+		documentAIBreaker.Record(err)
+		shared.Record(client, err)
+		return response, err
+	})
+	if errors.Is(err, errBackendBusy) || errors.Is(err, errCircuitOpen) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	if err != nil {
+		logErrorf("Document AI request failed: %v", err)
+		if quotaErr := asQuotaExceeded(err); quotaErr != nil {
+			return nil, quotaErr
+		}
+		// Document AI rejects documents it can't read, such as a password
+		// protected PDF, as invalid arguments; retrying won't help
+		if status.Code(err) == codes.InvalidArgument {
+			return nil, newClientError("Document AI rejected the document: %v", err)
+		}
+		if status.Code(err) == codes.DeadlineExceeded {
+			return nil, fmt.Errorf("%w: Document AI did not answer in time: %v", context.DeadlineExceeded, err)
+		}
+		return nil, fmt.Errorf("failed to process document: %v", err)
+	}
+	logDebugf("Received response from Document AI (%d bytes)", proto.Size(response.Document))
+	noText := strings.TrimSpace(response.Document.Text) == "" && len(response.Document.Entities) == 0
+	if noText {
+		logWarnf("Document AI found no text in the image")
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to download image, status code: %d", resp.StatusCode)
+	if req.Mode == modeText {
+		result := &ocrResult{NoTextDetected: noText}
+		if response.Document.Text != "" && req.wantsFullText() {
+			result.Texts = []string{response.Document.Text}
+		}
+		if req.IncludeBlocks {
+			result.Blocks = buildBlocks(response.Document)
+		}
+		if req.IncludeWordConfidence {
+			result.Words, result.WordConfidence = buildWordConfidences(response.Document)
+		}
+		return result, nil
 	}
 
-	return ioutil.ReadAll(resp.Body)
-}
+	observedTypes.Record(processorID, response.Document.Entities)
 
-func extractDataFromDocument(document *documentaipb.Document, instructions string) ([]string, *Receipt) {
-	var texts []string
-	receipt := &Receipt{
-		Items:  []ReceiptItem{},
-		Fields: []ReceiptField{},
+	// Extract text and structured data from the response
+	texts, receipt := extractDataFromDocument(response.Document, req.Instructions, req.ExpectedCurrency)
+
+	if req.Locale != "" {
+		if receipt.TotalAmountValue != 0 {
+			receipt.FormattedTotal = formatAmount(receipt.TotalAmountValue, receipt.Currency, language.Make(req.Locale))
+		}
 	}
 
-	if document.Text != "" {
+	if receipt.Date == "" && hasCaptureTime {
+		logDebugf("No receipt date found, falling back to EXIF capture time %s", captureTime)
+		receipt.DateSource = sourceEXIF
+		receipt.ExifTimestamp = captureTime.Format("2006-01-02T15:04:05")
+		receipt.NormalizedDate = captureTime.Format("2006-01-02")
+		receipt.NormalizedTime = captureTime.Format("15:04:05")
+	}
+
+	if req.RequireConfidence > 0 {
+		suppressLowConfidenceFields(receipt, req.RequireConfidence)
+	}
+
+	receipt.ImageHash = hex.EncodeToString(imageHash[:])
+	if duplicateDetector != nil {
+		receipt.DuplicateSuspected = duplicateDetector.Check(receipt.ImageHash)
+	}
+
+	assignItemIDs(receipt.Items, receipt.ImageHash)
+	sortItems(receipt.Items, req.SortItems)
+
+	result := &ocrResult{Receipt: receipt, NoTextDetected: noText}
+	if req.wantsFullText() {
+		result.Texts = texts
+	}
+	if req.Debug && debugResponsesAllowed() {
+		result.Debug = buildDebugInfo(response.Document)
+		result.Debug.MimeType = mimeType
+		result.Debug.DeclaredMimeType = declaredType
+	}
+	if req.IncludeBlocks {
+		result.Blocks = buildBlocks(response.Document)
+	}
+	if req.IncludeWordConfidence {
+		result.Words, result.WordConfidence = buildWordConfidences(response.Document)
+	}
+	if !req.IncludePropertyConfidence {
+		for i := range receipt.Items {
+			receipt.Items[i].PropertyConfidence = nil
+		}
+	}
+
+	return result, nil
+}
+
+// durationFromEnv reads a positive duration from the named variable, either
+// a plain number of seconds ("30") or a Go duration ("500ms", "2m").
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		if seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		return fallback
+	}
+	if duration, err := time.ParseDuration(raw); err == nil && duration > 0 {
+		return duration
+	}
+	return fallback
+}
+
+// intFromEnv reads a positive integer from the named variable.
+func intFromEnv(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// floatFromEnv reads a positive number from the named variable.
+func floatFromEnv(name string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// nonNegativeFloatFromEnv reads a number from the named variable, accepting
+// zero.
+func nonNegativeFloatFromEnv(name string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
+
+// documentAITimeout returns the deadline for a Document AI call. Clients can
+// ask for a different one, but it is clamped to MAX_TIMEOUT.
+func documentAITimeout(requestedSeconds int) time.Duration {
+	timeout := durationFromEnv("DOCUMENT_AI_TIMEOUT", 30*time.Second)
+	if requestedSeconds > 0 {
+		timeout = time.Duration(requestedSeconds) * time.Second
+	}
+	if maxTimeout := durationFromEnv("MAX_TIMEOUT", 120*time.Second); timeout > maxTimeout {
+		timeout = maxTimeout
+	}
+	return timeout
+}
+
+// defaultDocumentAIFieldMask lists the Document fields the service reads.
+// Page geometry (layout bounding polygons) lives under pages, so it stays
+// available to anything that needs it.
+var defaultDocumentAIFieldMask = []string{"text", "entities", "pages"}
+
+// documentAIFieldMask returns the Document fields to request from Document
+// AI, configurable with DOCUMENT_AI_FIELD_MASK. "*" requests the full
+// Document and returns no paths. When blocks or words are requested, pages
+// is always included so they are returned.
+func documentAIFieldMask(needPages bool) []string {
+	paths := envList("DOCUMENT_AI_FIELD_MASK", defaultDocumentAIFieldMask)
+	if len(paths) == 1 && paths[0] == "*" {
+		return nil
+	}
+	if needPages {
+		for _, path := range paths {
+			if path == "pages" {
+				return paths
+			}
+		}
+		paths = append(append([]string{}, paths...), "pages")
+	}
+	return paths
+}
+
+func defaultLanguageHints() []string {
+	var hints []string
+	for _, hint := range strings.Split(os.Getenv("DEFAULT_LANGUAGE_HINTS"), ",") {
+		if hint = strings.TrimSpace(hint); hint != "" {
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+func validateLanguageHints(hints []string) error {
+	for _, hint := range hints {
+		if _, err := language.Parse(hint); err != nil {
+			return fmt.Errorf("invalid language hint %q: must be a BCP-47 language code", hint)
+		}
+	}
+	return nil
+}
+
+// parsePageSelection parses a 1-based page selection such as "1", "1-2" or
+// "1,3-4" into sorted, de-duplicated page numbers. Pages beyond MAX_PAGES
+// (default 15, Document AI's limit for online processing) are rejected.
+func parsePageSelection(selection string) ([]int32, error) {
+	maxPages := intFromEnv("MAX_PAGES", 15)
+	invalid := fmt.Errorf("invalid pages %q: expected page numbers or ranges like \"1\", \"1-2\" or \"1,3\" between 1 and %d", selection, maxPages)
+
+	selected := make(map[int32]bool)
+	for _, part := range strings.Split(selection, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil {
+			return nil, invalid
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(last)); err != nil {
+				return nil, invalid
+			}
+		}
+		if start < 1 || end < start || end > maxPages {
+			return nil, invalid
+		}
+		for page := start; page <= end; page++ {
+			selected[int32(page)] = true
+		}
+	}
+
+	pages := make([]int32, 0, len(selected))
+	for page := range selected {
+		pages = append(pages, page)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i] < pages[j] })
+	return pages, nil
+}
+
+var supportedDataURITypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/jpg":       true,
+	"image/png":       true,
+	"image/tiff":      true,
+	"application/pdf": true,
+}
+
+// parseDataURI decodes a "data:[<media type>][;base64],<data>" URI, as
+// produced by a browser canvas, without any network access. It returns the
+// decoded bytes and the declared media type.
+func parseDataURI(uri string) ([]byte, string, error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return nil, "", fmt.Errorf("must start with \"data:\"")
+	}
+	header, payload, found := strings.Cut(rest, ",")
+	if !found {
+		return nil, "", fmt.Errorf("missing ',' separator")
+	}
+
+	params := strings.Split(header, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+	if !supportedDataURITypes[mediaType] {
+		return nil, "", fmt.Errorf("unsupported media type %q: expected image/jpeg, image/png, image/tiff or application/pdf", mediaType)
+	}
+
+	if mediaType == "image/jpg" {
+		mediaType = "image/jpeg"
+	}
+	if err := checkDeclaredContentType(mediaType); err != nil {
+		return nil, "", err
+	}
+
+	isBase64 := false
+	for _, param := range params[1:] {
+		if strings.EqualFold(strings.TrimSpace(param), "base64") {
+			isBase64 = true
+		}
+	}
+
+	if isBase64 {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		return data, mediaType, err
+	}
+	decoded, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	return []byte(decoded), mediaType, nil
+}
+
+// validateImageSource checks that exactly one of image_url, base64_image and
+// data_uri is set, and that image_url is a remote URL.
+func validateImageSource(req OCRRequest) error {
+	if len(req.Images) > 0 {
+		if req.ImageURL != "" || req.Base64Image != "" || req.DataURI != "" {
+			return fmt.Errorf("images can't be combined with image_url, base64_image or data_uri")
+		}
+		return validateImageSources(req.Images)
+	}
+	sources := 0
+	for _, source := range []string{req.ImageURL, req.Base64Image, req.DataURI} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one of image_url, base64_image, data_uri or images must be set")
+	}
+	if strings.HasPrefix(req.ImageURL, "data:") {
+		return fmt.Errorf("image_url must be an http or https URL, send data: URIs in data_uri")
+	}
+	return nil
+}
+
+// downloadImage fetches image_url, retrying network errors and 5xx
+// responses up to IMAGE_DOWNLOAD_ATTEMPTS times (default 3) with a short
+// exponential backoff. 4xx responses fail straight away, and no retry is
+// started that would outlive ctx's deadline.
+func downloadImage(ctx context.Context, rawURL string, headers map[string]string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImageURL(u); err != nil {
+		return nil, err
+	}
+
+	attempts := intFromEnv("IMAGE_DOWNLOAD_ATTEMPTS", 3)
+	backoff := 200 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		data, retryable, err := fetchImage(ctx, u, headers)
+		if err == nil || !retryable || attempt >= attempts {
+			return data, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return nil, err
+		}
+
+		logWarnf("Image download attempt %d of %d failed, retrying in %s: %v", attempt, attempts, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, 2*time.Second)
+	}
+}
+
+// fetchImage makes a single download attempt and reports whether a failure
+// is worth retrying.
+func fetchImage(ctx context.Context, u *url.URL, headers map[string]string) ([]byte, bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	resp, err := imageHTTPClient.Do(request)
+	if err != nil {
+		retryable := !errors.Is(err, errImageHostForbidden) && ctx.Err() == nil
+		return nil, retryable, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode >= 500, fmt.Errorf("failed to download image, status code: %d", resp.StatusCode)
+	}
+	if err := checkDeclaredContentType(resp.Header.Get("Content-Type")); err != nil {
+		return nil, false, err
+	}
+
+	data, err := readBody(resp.Body, maxDocumentBytes())
+	if err != nil {
+		return nil, ctx.Err() == nil && !errors.Is(err, errDocumentTooLarge), err
+	}
+	return data, false, nil
+}
+
+// extractDataFromDocument builds the receipt from Document AI's entities,
+// falling back to the raw text. expectedCurrency, when the client gave one,
+// resolves amounts like "1.234" and is used when no currency is detected.
+func extractDataFromDocument(document *documentaipb.Document, instructions, expectedCurrency string) ([]string, *Receipt) {
+	var texts []string
+	receipt := &Receipt{
+		Items:  []ReceiptItem{},
+		Fields: []ReceiptField{},
+	}
+
+	if document.Text != "" {
 		texts = append(texts, document.Text)
 	}
 	isShopReceipt := false
 	if instructions != "" {
 		isShopReceipt = strings.Contains(strings.ToLower(instructions), "shop receipt")
-		log.Printf("Processing as shop receipt: %v", isShopReceipt)
+		logDebugf("Processing as shop receipt: %v", isShopReceipt)
 	}
 
 	for _, entity := range document.Entities {
@@ -331,103 +1351,669 @@ func extractDataFromDocument(document *documentaipb.Document, instructions strin
 		switch entity.Type {
 		case "receipt_merchant_name":
 			receipt.MerchantName = entity.MentionText
+			receipt.MerchantNameSource = sourceDocumentAI
+		case "receipt_merchant_address":
+			receipt.MerchantAddress = strings.Join(strings.Fields(entity.MentionText), " ")
+		case "receipt_merchant_phone", "receipt_merchant_phone_number":
+			receipt.MerchantPhone = normalizePhoneNumber(entity.MentionText)
 		case "receipt_date":
 			receipt.Date = entity.MentionText
+			receipt.DateSource = sourceDocumentAI
+			date, timeOfDay := entityDate(entity)
+			receipt.NormalizedDate = date
+			if timeOfDay != "" {
+				receipt.NormalizedTime = timeOfDay
+			}
+		case "receipt_time", "purchase_time":
+			if receipt.NormalizedTime == "" {
+				receipt.NormalizedTime = normalizeTime(entity.MentionText)
+			}
 		case "receipt_total_amount":
 			receipt.TotalAmount = entity.MentionText
+			receipt.TotalAmountSource = sourceDocumentAI
+			if amount, currencyCode, ok := entityAmount(entity, expectedCurrency); ok {
+				receipt.TotalAmountValue = amount
+				if currencyCode != "" && receipt.Currency == "" {
+					receipt.Currency = normalizeCurrencyCode(currencyCode)
+				}
+			}
+		case "currency", "receipt_currency":
+			receipt.Currency = normalizeCurrencyCode(entity.MentionText)
+		case "receipt_subtotal", "net_amount":
+			if amount, _, ok := entityAmount(entity, expectedCurrency); ok {
+				receipt.Subtotal = amount
+			}
+		case "receipt_tax", "total_tax_amount":
+			if amount, _, ok := entityAmount(entity, expectedCurrency); ok {
+				receipt.Tax = amount
+			}
+		case "receipt_tip", "tip_amount":
+			if amount, _, ok := entityAmount(entity, expectedCurrency); ok {
+				receipt.Tip = amount
+			}
 		case "line_item":
-			item := ReceiptItem{}
+			item := ReceiptItem{Confidence: entity.Confidence}
+			item.Page, item.offset = entityPosition(entity)
+			if len(entity.Properties) > 0 {
+				item.PropertyConfidence = make(map[string]float32, len(entity.Properties))
+			}
 			for _, property := range entity.Properties {
+				item.PropertyConfidence[strings.TrimPrefix(property.Type, "line_item/")] = property.Confidence
+
 				switch property.Type {
 				case "line_item/description":
 					item.Description = property.MentionText
 				case "line_item/quantity":
 					item.Quantity = property.MentionText
 				case "line_item/price":
-					item.Price = property.MentionText
+					item.Price = disambiguateAmounts(normalizeSignedAmount(property.MentionText), expectedCurrency)
 				case "line_item/total_price":
-					item.TotalPrice = property.MentionText
+					item.TotalPrice = disambiguateAmounts(normalizeSignedAmount(property.MentionText), expectedCurrency)
+				case "line_item/unit_price":
+					item.UnitPrice = disambiguateAmounts(normalizeSignedAmount(property.MentionText), expectedCurrency)
+				case "line_item/product_code":
+					item.ProductCode = property.MentionText
+				case "line_item/unit":
+					item.Unit = property.MentionText
+				default:
+					if item.Extra == nil {
+						item.Extra = make(map[string]string)
+					}
+					item.Extra[strings.TrimPrefix(property.Type, "line_item/")] = property.MentionText
 				}
 			}
 			if item.Description != "" {
+				item.Source = sourceDocumentAI
+				reconcileItem(&item)
 				receipt.Items = append(receipt.Items, item)
 			}
 		}
 	}
 
-	if len(receipt.Items) == 0 && isShopReceipt && document.Text != "" {
-		log.Println("No structured items found, attempting to extract items from text")
-		extractItemsFromText(document.Text, receipt)
+	if len(receipt.Items) == 0 && document.Text != "" && os.Getenv("DISABLE_TEXT_FALLBACK") != "true" {
+		logDebugf("No structured items found, attempting to extract items from text (shop receipt: %v)", isShopReceipt)
+		text := document.Text
+		if os.Getenv("DISABLE_COLUMN_LAYOUT") != "true" {
+			if rows, ok := rowOrderedText(document); ok {
+				logDebugf("Using token positions to read items row by row")
+				text = rows
+			}
+		}
+		extractItemsFromText(disambiguateAmounts(text, expectedCurrency), receipt, !isShopReceipt, expectedCurrency)
+	}
+
+	receipt.DetectedLanguages = collectDetectedLanguages(document.Pages)
+	receipt.PageOrientations = collectPageOrientations(document.Pages)
+
+	if receipt.MerchantName != "" {
+		receipt.MerchantNameNormalized = normalizeMerchantName(receipt.MerchantName)
+		receipt.VendorID, receipt.MatchScore = matchVendor(receipt.MerchantName)
+	}
+
+	if receipt.MerchantPhone == "" && document.Text != "" {
+		receipt.MerchantPhone = extractPhoneFromText(document.Text)
+	}
+
+	if document.Text != "" {
+		receipt.TransactionNumber = extractTransactionNumber(document.Text)
+		receipt.Cashier = extractCashier(document.Text)
+		receipt.FiscalNumber = extractFiscalNumber(document.Text)
+		receipt.LoyaltyCard = extractLoyaltyCard(document.Text)
+	}
+	receipt.Barcodes = collectBarcodes(document.Pages)
+
+	if document.Text != "" && (receipt.Subtotal == 0 || receipt.Tax == 0) {
+		lines := strings.Split(disambiguateAmounts(document.Text, expectedCurrency), "\n")
+		labeled := findLabeledAmounts(lines, envList("TOTAL_KEYWORDS", defaultTotalKeywords))
+		if receipt.Subtotal == 0 && labeled.hasSubtotal {
+			receipt.Subtotal = labeled.subtotal
+		}
+		if receipt.Tax == 0 && labeled.hasTax {
+			receipt.Tax = labeled.tax
+		}
+	}
+	if receipt.Currency == "" {
+		receipt.Currency = detectCurrency(receipt.TotalAmount)
+	}
+	if receipt.Currency == "" && document.Text != "" {
+		receipt.Currency = detectCurrency(document.Text)
+	}
+	if receipt.Currency != "" {
+		receipt.CurrencySource = "detected"
+	} else if expectedCurrency != "" {
+		receipt.Currency = expectedCurrency
+		receipt.CurrencySource = "expected"
+	} else if inferred := inferredCurrency(receipt); inferred != "" {
+		receipt.Currency = inferred
+		receipt.CurrencySource = "inferred"
+	} else if defaultCurrency := os.Getenv("DEFAULT_CURRENCY"); defaultCurrency != "" {
+		receipt.Currency = normalizeCurrencyCode(defaultCurrency)
+		receipt.CurrencySource = "default"
+	}
+	receipt.IsRefund = receipt.TotalAmountValue < 0
+	filterImplausibleItems(receipt)
+	if maxItems := intFromEnv("MAX_ITEMS", defaultMaxItems); len(receipt.Items) > maxItems {
+		logWarnf("Found %d line items, keeping the first %d", len(receipt.Items), maxItems)
+		receipt.Items = receipt.Items[:maxItems]
+		receipt.Truncated = true
 	}
+	indexItems(receipt.Items)
+	stripItemCodes(receipt.Items)
+	roundReceiptAmounts(receipt)
+	reconcileTotals(receipt)
+	summarizeItems(receipt)
+	setMinorUnitAmounts(receipt)
 
 	return texts, receipt
 }
 
-func extractItemsFromText(text string, receipt *Receipt) {
-	lines := strings.Split(text, "\n")
-	priceRegex := regexp.MustCompile(`(\d+[.,]\d{2})`)
-	var prices []float64
-	for _, line := range lines {
-		if strings.Contains(strings.ToLower(line), "total") ||
-			strings.Contains(strings.ToLower(line), "suma") ||
-			strings.Contains(strings.ToLower(line), "razem") {
-			matches := priceRegex.FindAllString(line, -1)
-			for _, match := range matches {
-				// Replace comma with dot for proper float parsing
-				match = strings.Replace(match, ",", ".", -1)
-				price, err := strconv.ParseFloat(match, 64)
-				if err == nil {
-					prices = append(prices, price)
-				}
+// defaultMaxItems caps line items per receipt so a pathological OCR result
+// can't produce a runaway response.
+const defaultMaxItems = 500
+
+// inferredCurrency guesses the currency from the merchant's country when
+// INFER_CURRENCY_FROM_ADDRESS=true.
+func inferredCurrency(receipt *Receipt) string {
+	if os.Getenv("INFER_CURRENCY_FROM_ADDRESS") != "true" || receipt.MerchantAddress == "" {
+		return ""
+	}
+	return inferCurrencyFromAddress(receipt.MerchantAddress)
+}
+
+// reconcileTotals checks that subtotal + tax + tip matches the total within a
+// small tolerance. Many European receipts print a tax-inclusive subtotal, so
+// subtotal + tip matching the total is also accepted.
+func reconcileTotals(receipt *Receipt) {
+	total := receipt.TotalAmountValue
+	if total == 0 || receipt.Subtotal == 0 {
+		return
+	}
+
+	const tolerance = 0.02
+	discrepancy := total - (receipt.Subtotal + receipt.Tax + receipt.Tip)
+	reconciles := math.Abs(discrepancy) <= tolerance
+	if !reconciles && receipt.Tax != 0 {
+		taxInclusive := total - (receipt.Subtotal + receipt.Tip)
+		if math.Abs(taxInclusive) <= tolerance {
+			reconciles = true
+		}
+	}
+
+	receipt.TotalsReconcile = &reconciles
+	if !reconciles {
+		receipt.TotalsDiscrepancy = roundMoney(discrepancy, receipt.Currency)
+	}
+}
+
+// itemLineTotal returns what an item contributes to the receipt total: its
+// total price when known, otherwise its price.
+func itemLineTotal(item ReceiptItem) (float64, bool) {
+	if item.TotalPrice != "" {
+		return parseAmount(item.TotalPrice)
+	}
+	return parseAmount(item.Price)
+}
+
+// summarizeItems sets the item count and the sum of item line totals, and
+// records how far that sum is from the receipt total when both are known.
+func summarizeItems(receipt *Receipt) {
+	receipt.ItemCount = len(receipt.Items)
+	sum := 0.0
+	for _, item := range receipt.Items {
+		if amount, ok := itemLineTotal(item); ok {
+			sum += amount
+		}
+	}
+	receipt.ItemsPriceSum = roundMoney(sum, receipt.Currency)
+
+	const tolerance = 0.02
+	// Dropped items would show up as a discrepancy, so skip it when truncated
+	if receipt.TotalAmountValue != 0 && receipt.ItemCount > 0 && !receipt.Truncated {
+		if discrepancy := receipt.TotalAmountValue - receipt.ItemsPriceSum; math.Abs(discrepancy) > tolerance {
+			receipt.ItemsDiscrepancy = roundMoney(discrepancy, receipt.Currency)
+		}
+	}
+}
+
+// reconcileItem fills in whichever one of quantity, price and total price is
+// missing when the other two are numeric, and marks the item as Computed.
+// Items with more than one missing value, or where a computed quantity isn't
+// a sensible number, are left unchanged. It is only applied to Document AI
+// line items, where price is known to be the unit price.
+func reconcileItem(item *ReceiptItem) {
+	quantity, hasQuantity := parseQuantity(item.Quantity)
+	price, hasPrice := parseAmount(item.Price)
+	total, hasTotal := parseAmount(item.TotalPrice)
+
+	switch {
+	case item.Quantity == "" && hasPrice && hasTotal && item.Price != "" && item.TotalPrice != "":
+		if price == 0 {
+			return
+		}
+		quantity = math.Round(total/price*1000) / 1000
+		if quantity <= 0 || math.Abs(quantity*price-total) > 0.01 {
+			return
+		}
+		item.Quantity = strconv.FormatFloat(quantity, 'f', -1, 64)
+	case item.Price == "" && hasQuantity && hasTotal && item.TotalPrice != "":
+		if quantity == 0 {
+			return
+		}
+		item.Price = fmt.Sprintf("%.2f", total/quantity)
+	case item.TotalPrice == "" && hasQuantity && hasPrice && item.Price != "":
+		item.TotalPrice = fmt.Sprintf("%.2f", quantity*price)
+	default:
+		return
+	}
+	item.Computed = true
+}
+
+func parseQuantity(s string) (float64, bool) {
+	quantity, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(s), ",", ".", -1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return quantity, true
+}
+
+// assignItemIDs gives each item a short ID derived from the source hash, its
+// position in the extracted order and its content, so processing the same
+// image again yields the same IDs. It must run before items are re-sorted.
+func assignItemIDs(items []ReceiptItem, sourceHash string) {
+	for i := range items {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s|%s|%s", sourceHash, i, items[i].Description, items[i].Price, items[i].TotalPrice)))
+		items[i].ID = hex.EncodeToString(sum[:6])
+	}
+}
+
+// sortItems reorders items in place. Items without a parseable price are
+// kept at the end when sorting by price.
+func sortItems(items []ReceiptItem, order string) {
+	switch order {
+	case "price_desc", "price_asc":
+		sort.SliceStable(items, func(i, j int) bool {
+			pi, okI := itemLineTotal(items[i])
+			pj, okJ := itemLineTotal(items[j])
+			if okI != okJ {
+				return okI
+			}
+			if order == "price_desc" {
+				return pi > pj
+			}
+			return pi < pj
+		})
+	case "name":
+		sort.SliceStable(items, func(i, j int) bool {
+			return strings.ToLower(items[i].Description) < strings.ToLower(items[j].Description)
+		})
+	}
+}
+
+// collectDetectedLanguages merges the per-page language guesses, keeping
+// the highest confidence seen for each language.
+func collectDetectedLanguages(pages []*documentaipb.Document_Page) []DetectedLanguage {
+	best := make(map[string]float32)
+	for _, page := range pages {
+		for _, detected := range page.DetectedLanguages {
+			if detected.LanguageCode == "" {
+				continue
 			}
+			if confidence, ok := best[detected.LanguageCode]; !ok || detected.Confidence > confidence {
+				best[detected.LanguageCode] = detected.Confidence
+			}
+		}
+	}
+	if len(best) == 0 {
+		return nil
+	}
+
+	languages := make([]DetectedLanguage, 0, len(best))
+	for code, confidence := range best {
+		languages = append(languages, DetectedLanguage{Code: code, Confidence: confidence})
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Confidence != languages[j].Confidence {
+			return languages[i].Confidence > languages[j].Confidence
+		}
+		return languages[i].Code < languages[j].Code
+	})
+	return languages
+}
+
+// labelledPhoneRegex and internationalPhoneRegex only accept numbers that are
+// labelled as a phone or written in international form, so tax IDs (NIP) and
+// card numbers don't match.
+var (
+	labelledPhoneRegex      = regexp.MustCompile(`(?i)\b(?:tel|telefon|phone|ph)\b\.?[:\s]*((?:\+|00)?\d[\d\s\-()]{5,}\d)`)
+	internationalPhoneRegex = regexp.MustCompile(`(?:^|\s)(\+\d{2,3}[\s\-]?\d[\d\s\-]{6,}\d)`)
+)
+
+func extractPhoneFromText(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if match := labelledPhoneRegex.FindStringSubmatch(line); match != nil {
+			return normalizePhoneNumber(match[1])
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if match := internationalPhoneRegex.FindStringSubmatch(line); match != nil {
+			return normalizePhoneNumber(match[1])
+		}
+	}
+	return ""
+}
+
+// normalizePhoneNumber strips formatting from a phone number, keeping only
+// digits and a leading "+" when the country code is known.
+func normalizePhoneNumber(phone string) string {
+	phone = strings.TrimSpace(phone)
+	international := strings.HasPrefix(phone, "+")
+
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
 		}
 	}
+	normalized := digits.String()
+	if normalized == "" {
+		return ""
+	}
+
+	if !international && strings.HasPrefix(normalized, "00") {
+		normalized = normalized[2:]
+		international = true
+	}
+	if international {
+		return "+" + normalized
+	}
+	return normalized
+}
+
+// Keywords matched case-insensitively anywhere in a line. Lines with a total
+// keyword are candidates for the receipt total; lines with either kind of
+// keyword are never taken as items.
+var (
+	defaultTotalKeywords    = []string{"total", "suma", "razem", "do zapłaty"}
+	defaultSkipLineKeywords = []string{"receipt", "paragon", "thank you", "dziękujemy"}
+)
 
-	if len(prices) > 0 {
-		sort.Float64s(prices)
-		for i, j := 0, len(prices)-1; i < j; i, j = i+1, j-1 {
-			prices[i], prices[j] = prices[j], prices[i]
+// envList reads a comma-separated list from the named variable, falling
+// back to defaults when it is unset.
+func envList(name string, defaults []string) []string {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return defaults
+	}
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
 		}
-		if receipt.TotalAmount == "" {
-			receipt.TotalAmount = fmt.Sprintf("%.2f", prices[0])
+	}
+	return values
+}
+
+func containsAnyKeyword(line string, keywords []string) bool {
+	lower := strings.ToLower(line)
+	for _, keyword := range keywords {
+		if strings.Contains(lower, strings.ToLower(keyword)) {
+			return true
 		}
 	}
+	return false
+}
+
+// strictItemSkipWords are non-item lines common on restaurant and service
+// receipts: taxes, tips and payment details.
+var strictItemSkipWords = []string{
+	"vat", "ptu", "tax", "podatek", "tip", "napiwek", "service", "serwis",
+	"change", "reszta", "cash", "gotówka", "karta", "card", "visa", "mastercard",
+	"płatność", "payment",
+}
+
+// extractItemsFromText finds line items in raw text. In strict mode, used
+// when the caller didn't say it's a shop receipt, only lines that carry
+// their own description are taken, and tax, tip and payment lines are
+// skipped, so layouts other than grocery receipts don't produce junk items.
+// A labelled total is written with the decimals of the receipt's currency,
+// or of expectedCurrency when none is printed.
+func extractItemsFromText(text string, receipt *Receipt, strict bool, expectedCurrency string) {
+	totalKeywords := envList("TOTAL_KEYWORDS", defaultTotalKeywords)
+	skipKeywords := envList("SKIP_LINE_KEYWORDS", defaultSkipLineKeywords)
+
+	lines := strings.Split(text, "\n")
+	labeled := findLabeledAmounts(lines, totalKeywords)
+	if labeled.hasTotal && receipt.TotalAmount == "" {
+		currencyCode := receipt.Currency
+		if currencyCode == "" {
+			currencyCode = detectCurrency(text)
+		}
+		if currencyCode == "" {
+			currencyCode = expectedCurrency
+		}
+		receipt.TotalAmount = formatMinorUnits(labeled.total, currencyCode)
+		// Set the value too, since "500" or "1.250" wouldn't parse back
+		// the same
+		receipt.TotalAmountValue = roundMoney(labeled.total, currencyCode)
+		receipt.TotalAmountSource = sourceTextFallback
+	}
 
 	var currentItem string
 	for i, line := range lines {
-		if strings.Contains(strings.ToLower(line), "total") ||
-			strings.Contains(strings.ToLower(line), "suma") ||
-			strings.Contains(strings.ToLower(line), "razem") ||
-			strings.Contains(strings.ToLower(line), "receipt") ||
-			strings.Contains(strings.ToLower(line), "paragon") ||
-			strings.Contains(strings.ToLower(line), "thank you") ||
-			strings.Contains(strings.ToLower(line), "dziękujemy") {
+		if labeled.labelLines[i] || containsAnyKeyword(line, totalKeywords) || containsAnyKeyword(line, skipKeywords) {
+			continue
+		}
+
+		if strict && containsAnyWord(line, strictItemSkipWords) {
+			continue
+		}
+
+		line, taxRate := splitVATMarker(line)
+
+		if weighted, ok := parseWeightedLine(line); ok {
+			description := weighted.description
+			if description == "" && i > 0 && len(findAmounts(lines[i-1])) == 0 {
+				description = strings.TrimSpace(lines[i-1])
+			}
+			receipt.Items = append(receipt.Items, ReceiptItem{
+				Description: description,
+				Quantity:    weighted.quantity,
+				Unit:        weighted.unit,
+				Price:       weighted.total,
+				TotalPrice:  weighted.total,
+				UnitPrice:   weighted.unitPrice,
+				TaxRate:     taxRate,
+				Source:      sourceTextFallback,
+				Page:        1,
+				offset:      int64(i),
+			})
 			continue
 		}
 
-		priceMatches := priceRegex.FindAllString(line, -1)
+		// Pull out weight/volume tokens first so "0,450 kg" isn't read as a price
+		quantity, unit, rest := extractQuantityUnit(line)
+		priceMatches := findAmounts(rest)
 		if len(priceMatches) > 0 {
-			if len(strings.TrimSpace(line)) == len(priceMatches[0]) && i > 0 {
-				currentItem = strings.TrimSpace(lines[i-1])
+			priceOnly := strings.TrimSpace(rest) == strings.TrimSpace(priceMatches[0])
+			if strict && (priceOnly || !strings.ContainsFunc(removeAmounts(rest), unicode.IsLetter)) {
+				continue
+			}
+			if priceOnly && i > 0 {
+				previousQuantity, previousUnit, previous := extractQuantityUnit(lines[i-1])
+				if unit == "" {
+					quantity, unit = previousQuantity, previousUnit
+				}
+				currentItem = strings.TrimSpace(previous)
 			} else {
-				currentItem = strings.TrimSpace(priceRegex.ReplaceAllString(line, ""))
+				currentItem = strings.TrimSpace(removeAmounts(rest))
 			}
-			priceStr := strings.Replace(priceMatches[0], ",", ".", -1)
+			if quantity == "" {
+				quantity, currentItem = extractLeadingQuantity(currentItem)
+			}
+			priceStr := normalizePriceMatch(priceMatches[0])
 			price, err := strconv.ParseFloat(priceStr, 64)
-			if err == nil && price > 0 && price < 10000 {
+			// Implausible prices are dropped once the currency is known, see
+			// filterImplausibleItems
+			if err == nil && price != 0 {
 				receipt.Items = append(receipt.Items, ReceiptItem{
 					Description: currentItem,
+					Quantity:    quantity,
+					Unit:        unit,
 					Price:       priceStr,
+					TaxRate:     taxRate,
+					Source:      sourceTextFallback,
+					Page:        1,
+					offset:      int64(i),
 				})
 			}
 		}
 	}
 }
 
+// containsAnyWord reports whether line contains any of words as a whole
+// word, ignoring case.
+func containsAnyWord(line string, words []string) bool {
+	fields := strings.FieldsFunc(strings.ToLower(line), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, field := range fields {
+		for _, word := range words {
+			if field == word {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// weightedLineRegex matches a weighed or measured item priced per unit, e.g.
+// "0,532 kg x 8,99 zł/kg = 4,78 zł", capturing the quantity, unit, unit
+// price, price unit and line total.
+var weightedLineRegex = regexp.MustCompile(`(?i)(?:^|\s)(\d+[.,]\d+|\d+)\s?(kg|g|l|ml)\.?\s*[x×*]\s*(\d+[.,]\d{2})\s*(?:zł|pln|eur|€)?\s*/\s*(kg|g|l|ml)\s*=?\s*(-?\d+[.,]\d{2})-?\s*(?:zł|pln|eur|€)?`)
+
+type weightedLine struct {
+	description string
+	quantity    string
+	unit        string
+	unitPrice   string
+	total       string
+}
+
+// parseWeightedLine recognises the "quantity unit x unit price/unit = total"
+// layout of weighed goods. The match is only accepted when quantity times
+// unit price gives the total, allowing for the till's rounding.
+func parseWeightedLine(line string) (weightedLine, bool) {
+	match := weightedLineRegex.FindStringSubmatchIndex(line)
+	if match == nil {
+		return weightedLine{}, false
+	}
+	group := func(n int) string { return line[match[2*n]:match[2*n+1]] }
+
+	unit := strings.ToLower(group(2))
+	if !strings.EqualFold(unit, group(4)) {
+		return weightedLine{}, false
+	}
+	if unit == "l" {
+		unit = "L"
+	}
+
+	quantity, ok := parseQuantity(group(1))
+	if !ok {
+		return weightedLine{}, false
+	}
+	unitPrice, _ := strconv.ParseFloat(normalizePriceMatch(group(3)), 64)
+	total, _ := strconv.ParseFloat(normalizePriceMatch(group(5)), 64)
+	if math.Abs(quantity*unitPrice-math.Abs(total)) > 0.011 {
+		return weightedLine{}, false
+	}
+
+	return weightedLine{
+		description: strings.TrimSpace(line[:match[0]] + " " + line[match[1]:]),
+		quantity:    strings.Replace(group(1), ",", ".", -1),
+		unit:        unit,
+		unitPrice:   normalizePriceMatch(group(3)),
+		total:       normalizePriceMatch(group(5)),
+	}, true
+}
+
+// extractQuantityUnit finds a quantity followed by a unit of measure (e.g.
+// "0,450 kg", "1,5 L", "2 szt") and returns the quantity, the normalized unit
+// and the line with that token removed.
+func extractQuantityUnit(line string) (string, string, string) {
+	match := quantityUnitRegex.FindStringSubmatchIndex(line)
+	if match == nil {
+		return "", "", line
+	}
+
+	quantity := strings.Replace(line[match[2]:match[3]], ",", ".", -1)
+	unit := strings.ToLower(line[match[4]:match[5]])
+	if unit == "l" {
+		unit = "L"
+	}
+	rest := line[:match[0]] + " " + line[match[1]:]
+	return quantity, unit, rest
+}
+
+// leadingQuantityRegex matches a small count at the start of an item line
+// ("3 Bread", "2x Mleko"). The count has no leading zero and must be followed
+// by a word, so years, product codes and prices are left alone.
+var leadingQuantityRegex = regexp.MustCompile(`^([1-9]\d?)(?:\s*[x×*])?\s+(\pL.*)$`)
+
+// extractLeadingQuantity splits a leading count of 1-99 off description and
+// returns it as the quantity together with the remaining description.
+func extractLeadingQuantity(description string) (string, string) {
+	match := leadingQuantityRegex.FindStringSubmatch(description)
+	if match == nil {
+		return "", description
+	}
+	return match[1], strings.TrimSpace(match[2])
+}
+
+// leadingItemCodeRegex matches a run of digits followed by a word at the
+// start of an item description ("5901234 Mleko 2%").
+var leadingItemCodeRegex = regexp.MustCompile(`^(\d+)\s+(\pL.*)$`)
+
+// quantityUnitRegex matches a quantity followed by a unit of measure
+// anywhere in an item line.
+var quantityUnitRegex = regexp.MustCompile(`(?i)(?:^|\s)(\d+(?:[.,]\d+)?)\s?(kg|g|l|ml|szt)\.?(?:\s|$)`)
+
+// leadingUnitRegex matches a unit of measure at the start of what follows a
+// number, so "1000 g Mąka" is read as a weight rather than a code.
+var leadingUnitRegex = regexp.MustCompile(`(?i)^(?:kg|g|l|ml|szt)\.?(?:\s|$)`)
+
+// stripItemCodes removes leading SKU/PLU codes of at least
+// STRIP_ITEM_CODE_MIN_DIGITS digits (and at most 14, the GTIN length) from
+// item descriptions. It does nothing when the variable is unset. The code
+// is kept as the product code when Document AI didn't report one.
+func stripItemCodes(items []ReceiptItem) {
+	minDigits := intFromEnv("STRIP_ITEM_CODE_MIN_DIGITS", 0)
+	if minDigits == 0 {
+		return
+	}
+	for i := range items {
+		match := leadingItemCodeRegex.FindStringSubmatch(items[i].Description)
+		if match == nil || len(match[1]) < minDigits || len(match[1]) > 14 || leadingUnitRegex.MatchString(match[2]) {
+			continue
+		}
+		items[i].RawDescription = items[i].Description
+		items[i].Description = strings.TrimSpace(match[2])
+		if items[i].ProductCode == "" {
+			items[i].ProductCode = match[1]
+		}
+	}
+}
+
 func sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	sendErrorResponseWithCode(w, message, "", statusCode)
+}
+
+// sendErrorResponseWithCode adds a machine-readable code clients can act on
+// without parsing the message.
+func sendErrorResponseWithCode(w http.ResponseWriter, message, code string, statusCode int) {
 	response := OCRResponse{
 		Success: false,
 		Error:   message,
+		Code:    code,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// installFakeProcessor makes processDocument use fake, through the
+// newDocumentProcessor seam, for the rest of the test.
+func installFakeProcessor(t *testing.T, fake documentProcessor) {
+	t.Helper()
+	previous, previousClient := newDocumentProcessor, documentAIClient
+	newDocumentProcessor = func(ctx context.Context) (documentProcessor, error) {
+		return fake, nil
+	}
+	documentAIClient = &sharedProcessor{}
+	t.Cleanup(func() {
+		newDocumentProcessor, documentAIClient = previous, previousClient
+	})
+}
+
+// testPNG returns a small valid PNG. Each size gives different bytes, so
+// requests for different sizes aren't coalesced.
+func testPNG(t *testing.T, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, size, size))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func ocrRequestBody(t *testing.T, content []byte) string {
+	t.Helper()
+	body, err := json.Marshal(OCRRequest{Base64Image: base64.StdEncoding.EncodeToString(content)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func postOCR(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleOCR(w, httptest.NewRequest(http.MethodPost, "/api/ocr", strings.NewReader(body)))
+	return w
+}
+
+func testReceiptDocument() *documentaipb.Document {
+	return &documentaipb.Document{
+		Text: "BIEDRONKA\nMLEKO 3,99\nSUMA PLN 3,99",
+		Entities: []*documentaipb.Document_Entity{
+			{Type: "receipt_merchant_name", MentionText: "BIEDRONKA", Confidence: 0.9},
+			{Type: "receipt_total_amount", MentionText: "3,99", Confidence: 0.9},
+		},
+	}
+}
+
+func TestHandleOCRWithFakeProcessor(t *testing.T) {
+	tests := []struct {
+		name       string
+		fake       *fakeDocumentProcessor
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name:       "success",
+			fake:       &fakeDocumentProcessor{document: testReceiptDocument()},
+			wantStatus: http.StatusOK,
+			wantCalls:  1,
+		},
+		{
+			name:       "transient error is retried",
+			fake:       &fakeDocumentProcessor{document: testReceiptDocument(), err: status.Error(codes.Unavailable, "connection reset"), failCalls: 1},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:       "permanent error is not retried",
+			fake:       &fakeDocumentProcessor{err: status.Error(codes.InvalidArgument, "unsupported document")},
+			wantStatus: http.StatusBadRequest,
+			wantCalls:  1,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeProcessor(t, tt.fake)
+
+			w := postOCR(t, ocrRequestBody(t, testPNG(t, 10+i)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if calls := tt.fake.Calls(); calls != tt.wantCalls {
+				t.Errorf("Document AI calls = %d, want %d", calls, tt.wantCalls)
+			}
+
+			var response OCRResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid response body: %v", err)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if response.Success || response.Error == "" {
+					t.Errorf("response = %+v, want an error", response)
+				}
+				return
+			}
+			if !response.Success || response.Receipt == nil {
+				t.Fatalf("response = %+v, want a receipt", response)
+			}
+			if response.Receipt.MerchantName != "BIEDRONKA" || response.Receipt.TotalAmountValue != 3.99 {
+				t.Errorf("receipt = %+v, want BIEDRONKA with total 3.99", response.Receipt)
+			}
+		})
+	}
+}
+
+func TestErrorStatusForDocumentAIFailures(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{status.Error(codes.InvalidArgument, "bad document"), http.StatusBadRequest},
+		{status.Error(codes.Internal, "backend failure"), http.StatusInternalServerError},
+		// Transient errors are retried until the deadline runs out
+		{status.Error(codes.Unavailable, "still down"), http.StatusGatewayTimeout},
+		{status.Error(codes.DeadlineExceeded, "too slow"), http.StatusGatewayTimeout},
+	}
+	for i, tt := range tests {
+		// Every attempt fails, so transient errors surface after retrying
+		installFakeProcessor(t, &fakeDocumentProcessor{err: tt.err})
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		_, err := processDocument(ctx, OCRRequest{content: testPNG(t, 20+i)})
+		cancel()
+		if err == nil {
+			t.Fatalf("%v: processDocument succeeded, want an error", tt.err)
+		}
+		if got := errorStatus(err); got != tt.want {
+			t.Errorf("%v: errorStatus = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestErrorStatusForClientDisconnect(t *testing.T) {
+	installFakeProcessor(t, &fakeDocumentProcessor{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := processDocument(ctx, OCRRequest{content: testPNG(t, 30)})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("processDocument error = %v, want context.Canceled", err)
+	}
+	if got := errorStatus(err); got != statusClientClosedRequest {
+		t.Errorf("errorStatus = %d, want %d", got, statusClientClosedRequest)
+	}
+}
+
+func TestDurationFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 30 * time.Second},
+		{"5", 5 * time.Second},
+		{"500ms", 500 * time.Millisecond},
+		{"2m", 2 * time.Minute},
+		{"0", 30 * time.Second},
+		{"-1s", 30 * time.Second},
+		{"soon", 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("TEST_DURATION", tt.value)
+			if got := durationFromEnv("TEST_DURATION", 30*time.Second); got != tt.want {
+				t.Errorf("durationFromEnv(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFakeDocumentProcessorError(t *testing.T) {
+	t.Setenv("DOCUMENT_AI_FAKE_ERROR", "unavailable")
+	t.Setenv("DOCUMENT_AI_FAKE_ERROR_CALLS", "1")
+	fake, err := loadFakeDocumentProcessor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Code(fake.err) != codes.Unavailable || fake.failCalls != 1 {
+		t.Errorf("fake = %+v, want Unavailable for the first call", fake)
+	}
+
+	t.Setenv("DOCUMENT_AI_FAKE_ERROR", "NOT_A_CODE")
+	if _, err := loadFakeDocumentProcessor(); err == nil {
+		t.Error("unknown gRPC code accepted")
+	}
+}
+
+func TestExtractQuantityUnit(t *testing.T) {
+	tests := []struct {
+		line         string
+		wantQuantity string
+		wantUnit     string
+		wantRest     string
+	}{
+		{"Banany 0,450 kg 3,99", "0.450", "kg", "Banany 3,99"},
+		{"Woda 1,5 L 2,49", "1.5", "L", "Woda 2,49"},
+		{"Jajka 10 szt. 8,99", "10", "szt", "Jajka 8,99"},
+		{"Mąka 2kg 4,99", "2", "kg", "Mąka 4,99"},
+		{"Sok 330 ml 3,49", "330", "ml", "Sok 3,49"},
+		{"Lody 5 lat 9,99", "", "", "Lody 5 lat 9,99"},
+		{"MLEKO 3,99", "", "", "MLEKO 3,99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			quantity, unit, rest := extractQuantityUnit(tt.line)
+			if quantity != tt.wantQuantity || unit != tt.wantUnit || strings.Join(strings.Fields(rest), " ") != tt.wantRest {
+				t.Errorf("extractQuantityUnit(%q) = %q, %q, %q, want %q, %q, %q", tt.line, quantity, unit, rest, tt.wantQuantity, tt.wantUnit, tt.wantRest)
+			}
+		})
+	}
+}
+
+// textItems runs the text fallback item parsing on text.
+func textItems(text string) []ReceiptItem {
+	receipt := &Receipt{}
+	extractItemsFromText(text, receipt, false, "")
+	return receipt.Items
+}
+
+func TestExtractItemsFromTextQuantityUnit(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want ReceiptItem
+	}{
+		{"unit on the item line", "Banany 0,450 kg 3,99", ReceiptItem{Description: "Banany", Quantity: "0.450", Unit: "kg", Price: "3.99"}},
+		{"unit on the line before the price", "Ser 0,25 kg\n4,50", ReceiptItem{Description: "Ser", Quantity: "0.25", Unit: "kg", Price: "4.50"}},
+		{"no unit", "MLEKO 3,99", ReceiptItem{Description: "MLEKO", Price: "3.99"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := textItems(tt.text)
+			if len(items) != 1 {
+				t.Fatalf("got %d items %+v, want 1", len(items), items)
+			}
+			got := items[0]
+			if got.Description != tt.want.Description || got.Quantity != tt.want.Quantity || got.Unit != tt.want.Unit || got.Price != tt.want.Price {
+				t.Errorf("item = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvList(t *testing.T) {
+	defaults := []string{"total"}
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", []string{"total"}},
+		{"summe, gesamt", []string{"summe", "gesamt"}},
+		{" , betrag ,", []string{"betrag"}},
+	}
+	for _, tt := range tests {
+		t.Setenv("TOTAL_KEYWORDS", tt.value)
+		if got := envList("TOTAL_KEYWORDS", defaults); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("envList with %q = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestConfigurableLineKeywords(t *testing.T) {
+	text := "BROT 2,49\nSUMME EUR 5,98\nKASSENBON 3,49\nKÄSE 3,49"
+	tests := []struct {
+		name          string
+		totalKeywords string
+		skipKeywords  string
+		wantItems     []string
+		wantTotal     string
+	}{
+		{"defaults", "", "", []string{"BROT", "SUMME EUR", "KASSENBON", "KÄSE"}, ""},
+		{"custom keywords", "summe", "kassenbon", []string{"BROT", "KÄSE"}, "5.98"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TOTAL_KEYWORDS", tt.totalKeywords)
+			t.Setenv("SKIP_LINE_KEYWORDS", tt.skipKeywords)
+			receipt := &Receipt{}
+			extractItemsFromText(text, receipt, false, "")
+			var descriptions []string
+			for _, item := range receipt.Items {
+				descriptions = append(descriptions, item.Description)
+			}
+			if strings.Join(descriptions, "|") != strings.Join(tt.wantItems, "|") {
+				t.Errorf("items = %q, want %q", descriptions, tt.wantItems)
+			}
+			if receipt.TotalAmount != tt.wantTotal {
+				t.Errorf("total = %q, want %q", receipt.TotalAmount, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestExtractLeadingQuantity(t *testing.T) {
+	tests := []struct {
+		description     string
+		wantQuantity    string
+		wantDescription string
+	}{
+		{"3 Bread", "3", "Bread"},
+		{"2x Mleko", "2", "Mleko"},
+		{"12 × Jajka", "12", "Jajka"},
+		{"2024 Kalendarz", "", "2024 Kalendarz"},
+		{"05 Kod", "", "05 Kod"},
+		{"100 Gramów", "", "100 Gramów"},
+		{"Bread", "", "Bread"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			quantity, description := extractLeadingQuantity(tt.description)
+			if quantity != tt.wantQuantity || description != tt.wantDescription {
+				t.Errorf("extractLeadingQuantity(%q) = %q, %q, want %q, %q", tt.description, quantity, description, tt.wantQuantity, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestLeadingQuantityInTextItems(t *testing.T) {
+	items := textItems("3 Bread 7,50\nBanany 0,450 kg 3,99")
+	if len(items) != 2 {
+		t.Fatalf("got %d items %+v, want 2", len(items), items)
+	}
+	if items[0].Quantity != "3" || items[0].Description != "Bread" {
+		t.Errorf("item = %+v, want 3 × Bread", items[0])
+	}
+	// A weight already gives the quantity
+	if items[1].Quantity != "0.450" || items[1].Description != "Banany" {
+		t.Errorf("item = %+v, want 0.450 kg of Banany", items[1])
+	}
+}
+
+func TestParseWeightedLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want weightedLine
+		ok   bool
+	}{
+		{"Banany 0,532 kg x 8,99 zł/kg = 4,78 zł", weightedLine{"Banany", "0.532", "kg", "8.99", "4.78"}, true},
+		{"0,532kg × 8,99/kg 4,78", weightedLine{"", "0.532", "kg", "8.99", "4.78"}, true},
+		{"Sok 1,5 L * 4,00 PLN/l = 6,00", weightedLine{"Sok", "1.5", "L", "4.00", "6.00"}, true},
+		{"Ser 250 g x 0,04/g = 10,00 A", weightedLine{"Ser A", "250", "g", "0.04", "10.00"}, true},
+		// Quantity times unit price must give the total
+		{"Banany 0,532 kg x 8,99 zł/kg = 9,99 zł", weightedLine{}, false},
+		// The price unit must be the quantity's unit
+		{"Banany 0,532 kg x 8,99 zł/l = 4,78 zł", weightedLine{}, false},
+		{"Banany 0,450 kg 3,99", weightedLine{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseWeightedLine(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseWeightedLine(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestWeightedLineInTextItems(t *testing.T) {
+	items := textItems("POMIDORY\n0,532 kg x 8,99 zł/kg = 4,78 zł")
+	if len(items) != 1 {
+		t.Fatalf("got %d items %+v, want 1", len(items), items)
+	}
+	want := ReceiptItem{Description: "POMIDORY", Quantity: "0.532", Unit: "kg", Price: "4.78", TotalPrice: "4.78", UnitPrice: "8.99"}
+	got := items[0]
+	if got.Description != want.Description || got.Quantity != want.Quantity || got.Unit != want.Unit || got.Price != want.Price || got.TotalPrice != want.TotalPrice || got.UnitPrice != want.UnitPrice {
+		t.Errorf("item = %+v, want %+v", got, want)
+	}
+}
+
+func TestDownloadImageRetries(t *testing.T) {
+	t.Setenv("ALLOW_PRIVATE_IMAGE_HOSTS", "true")
+	t.Setenv("IMAGE_DOWNLOAD_ATTEMPTS", "3")
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantErr   bool
+		wantCalls int32
+	}{
+		{"success", []int{http.StatusOK}, false, 1},
+		{"5xx is retried", []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, false, 3},
+		{"4xx is not retried", []int{http.StatusNotFound, http.StatusOK}, true, 1},
+		{"gives up after IMAGE_DOWNLOAD_ATTEMPTS", []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK}, true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := calls.Add(1)
+				w.WriteHeader(tt.statuses[call-1])
+				w.Write([]byte("image"))
+			}))
+			defer server.Close()
+
+			data, err := downloadImage(context.Background(), server.URL, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadImage error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(data) != "image" {
+				t.Errorf("downloadImage = %q, want image", data)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("requests = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDownloadImageStopsAtDeadline(t *testing.T) {
+	t.Setenv("ALLOW_PRIVATE_IMAGE_HOSTS", "true")
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// The first backoff is 200ms, so no retry fits before the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := downloadImage(ctx, server.URL, nil); err == nil {
+		t.Fatal("downloadImage succeeded, want an error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestStripItemCodes(t *testing.T) {
+	tests := []struct {
+		name            string
+		minDigits       string
+		item            ReceiptItem
+		wantDescription string
+		wantCode        string
+	}{
+		{"disabled by default", "", ReceiptItem{Description: "5901234 Mleko 2%"}, "5901234 Mleko 2%", ""},
+		{"SKU", "4", ReceiptItem{Description: "5901234 Mleko 2%"}, "Mleko 2%", "5901234"},
+		{"too short for a code", "4", ReceiptItem{Description: "123 Mleko"}, "123 Mleko", ""},
+		{"longer than a GTIN", "4", ReceiptItem{Description: "123456789012345 Mleko"}, "123456789012345 Mleko", ""},
+		{"weight rather than a code", "4", ReceiptItem{Description: "1000 g Mąka"}, "1000 g Mąka", ""},
+		{"product code is kept", "4", ReceiptItem{Description: "5901234 Mleko", ProductCode: "PLU-1"}, "Mleko", "PLU-1"},
+		{"no word after the number", "4", ReceiptItem{Description: "5901234"}, "5901234", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("STRIP_ITEM_CODE_MIN_DIGITS", tt.minDigits)
+			items := []ReceiptItem{tt.item}
+			stripItemCodes(items)
+			got := items[0]
+			if got.Description != tt.wantDescription || got.ProductCode != tt.wantCode {
+				t.Errorf("item = %q with code %q, want %q with code %q", got.Description, got.ProductCode, tt.wantDescription, tt.wantCode)
+			}
+			wantRaw := ""
+			if got.Description != tt.item.Description {
+				wantRaw = tt.item.Description
+			}
+			if got.RawDescription != wantRaw {
+				t.Errorf("raw description = %q, want %q", got.RawDescription, wantRaw)
+			}
+		})
+	}
+}
+
+func TestHandleParseRejectsInvalidExpectedCurrency(t *testing.T) {
+	tests := []struct {
+		currency   string
+		wantStatus int
+	}{
+		{"eur", http.StatusOK},
+		{"", http.StatusOK},
+		{"EURO", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		body, _ := json.Marshal(ParseRequest{Text: "SUMA 1.234", ExpectedCurrency: tt.currency})
+		w := httptest.NewRecorder()
+		handleParse(w, httptest.NewRequest(http.MethodPost, "/api/parse", bytes.NewReader(body)))
+		if w.Code != tt.wantStatus {
+			t.Errorf("expected_currency %q: status = %d, want %d (body %s)", tt.currency, w.Code, tt.wantStatus, w.Body)
+		}
+	}
+}
+
+func TestDownloadImageRejectsDeclaredType(t *testing.T) {
+	t.Setenv("ALLOW_PRIVATE_IMAGE_HOSTS", "true")
+	allowContentTypes(t, "image/jpeg")
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	_, err := downloadImage(context.Background(), server.URL, nil)
+	if !errors.Is(err, errUnsupportedFormat) {
+		t.Fatalf("downloadImage error = %v, want errUnsupportedFormat", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1 with no retries", got)
+	}
+}
+
+func TestParseDataURIRejectsDeclaredType(t *testing.T) {
+	allowContentTypes(t, "application/pdf")
+	installFakeProcessor(t, &fakeDocumentProcessor{document: testReceiptDocument()})
+	_, err := processDocument(context.Background(), OCRRequest{DataURI: "data:image/png;base64,iVBORw0KGgo="})
+	if got := errorStatus(err); got != http.StatusUnsupportedMediaType {
+		t.Errorf("errorStatus(%v) = %d, want 415", err, got)
+	}
+}
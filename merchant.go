@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var defaultMerchantNoiseTokens = []string{
+	"sklep", "market", "supermarket", "hipermarket", "store", "shop",
+	"sp. z o.o.", "sp.z o.o.", "sp. z o. o.", "sp.k.", "sp. k.", "s.a.", "sa", "s.c.",
+	"ltd", "ltd.", "gmbh", "inc", "inc.", "llc",
+}
+
+var (
+	// An address usually starts at a street prefix or a Polish postal code
+	merchantAddressRegex = regexp.MustCompile(`(?i)(?:^|\s)(?:ul|al|pl|os)\.\s?|\b\d{2}-\d{3}\b`)
+	storeNumberRegex     = regexp.MustCompile(`(?i)^(?:nr\.?|no\.?)?\d+$`)
+	merchantNoiseRegex   = noiseTokenRegex(sortNoiseTokens(defaultMerchantNoiseTokens))
+)
+
+// configureMerchantNoiseTokens compiles MERCHANT_NOISE_TOKENS
+// (comma-separated) into the regex normalizeMerchantName strips, falling back
+// to the built-in defaults when the variable is unset.
+func configureMerchantNoiseTokens() {
+	tokens := defaultMerchantNoiseTokens
+	if raw := os.Getenv("MERCHANT_NOISE_TOKENS"); raw != "" {
+		tokens = nil
+		for _, token := range strings.Split(raw, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	merchantNoiseRegex = noiseTokenRegex(sortNoiseTokens(tokens))
+}
+
+// sortNoiseTokens returns a copy of tokens, longest first so multi-word
+// tokens are removed whole.
+func sortNoiseTokens(tokens []string) []string {
+	sorted := append([]string(nil), tokens...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	return sorted
+}
+
+// noiseTokenRegex matches any of tokens as a whole word. Alternatives are
+// tried in order, so tokens must already be sorted longest first.
+func noiseTokenRegex(tokens []string) *regexp.Regexp {
+	if len(tokens) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(tokens))
+	for i, token := range tokens {
+		quoted[i] = regexp.QuoteMeta(token)
+	}
+	return regexp.MustCompile(`(?i)(?:^|\s)(?:` + strings.Join(quoted, "|") + `)(?:\s|$)`)
+}
+
+// normalizeMerchantName cleans a printed merchant header into a canonical
+// name: it drops a trailing address, asterisks, store numbers and legal-form
+// or shop-type noise tokens, e.g. "BIEDRONKA 123 *** SKLEP" -> "BIEDRONKA".
+func normalizeMerchantName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if loc := merchantAddressRegex.FindStringIndex(name); loc != nil && loc[0] > 0 {
+		name = name[:loc[0]]
+	}
+	name = strings.NewReplacer("*", " ", "#", " ").Replace(name)
+
+	// Adjacent matches share a space, so repeat until nothing changes
+	for merchantNoiseRegex != nil && merchantNoiseRegex.MatchString(name) {
+		name = merchantNoiseRegex.ReplaceAllString(name, " ")
+	}
+
+	fields := strings.Fields(name)
+	var words []string
+	for i, word := range fields {
+		if storeNumberRegex.MatchString(word) {
+			continue
+		}
+		// "nr 45" is a store number split over two words
+		lowerWord := strings.ToLower(word)
+		if (lowerWord == "nr" || lowerWord == "nr." || lowerWord == "no.") && i+1 < len(fields) && storeNumberRegex.MatchString(fields[i+1]) {
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Trim(strings.Join(words, " "), " ,.-")
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestNormalizeMerchantName(t *testing.T) {
+	tests := []struct {
+		name  string
+		noise string
+		want  string
+	}{
+		{name: "BIEDRONKA 123 *** SKLEP", want: "BIEDRONKA"},
+		{name: "Jeronimo Martins Polska S.A. ul. Żniwna 5 62-025 Kostrzyn", want: "Jeronimo Martins Polska"},
+		{name: "LIDL sp. z o.o. sp.k. nr 45", want: "LIDL"},
+		{name: "Żabka #1234", want: "Żabka"},
+		{name: "  Carrefour   Market  ", want: "Carrefour"},
+		{name: "Apteka Zdrowie 00-950 Warszawa", want: "Apteka Zdrowie"},
+		{name: "ABC Shop", noise: "abc", want: "Shop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MERCHANT_NOISE_TOKENS", tt.noise)
+			configureMerchantNoiseTokens()
+			t.Cleanup(func() { merchantNoiseRegex = noiseTokenRegex(sortNoiseTokens(defaultMerchantNoiseTokens)) })
+			if got := normalizeMerchantName(tt.name); got != tt.want {
+				t.Errorf("normalizeMerchantName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
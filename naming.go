@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// dataMapFields are response fields holding maps whose keys are data, such
+// as Document AI property names, rather than field names, so their keys are
+// never renamed.
+var dataMapFields = map[string]bool{"extra": true, "property_confidence": true}
+
+// wantsCamelCase reports whether the client asked for camelCase JSON keys,
+// with ?case=camel or an Accept: application/json; profile=camelCase header.
+// Any other value keeps the default snake_case.
+func wantsCamelCase(r *http.Request) bool {
+	if r.URL.Query().Get("case") == "camel" {
+		return true
+	}
+	for _, accept := range r.Header.Values("Accept") {
+		if strings.Contains(strings.ReplaceAll(strings.ToLower(accept), `"`, ""), "profile=camelcase") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON encodes body to w, renaming the snake_case keys to camelCase
+// when the client asked for it. The renaming rewrites the encoded output, so
+// the response types only carry one set of JSON tags.
+func writeJSON(w http.ResponseWriter, r *http.Request, body interface{}) error {
+	if !wantsCamelCase(r) {
+		return json.NewEncoder(w).Encode(body)
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(encoded))
+	dec.UseNumber()
+	var out bytes.Buffer
+	if err := camelCaseValue(dec, &out, true); err != nil {
+		return fmt.Errorf("failed to rename keys: %v", err)
+	}
+	out.WriteByte('\n')
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// camelCaseValue copies the next JSON value from dec to out, converting
+// object keys when convertKeys is set. Key order is preserved.
+func camelCaseValue(dec *json.Decoder, out *bytes.Buffer, convertKeys bool) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		encoded, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+		out.Write(encoded)
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		out.WriteByte('{')
+		for i := 0; dec.More(); i++ {
+			token, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key := token.(string)
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			name := key
+			if convertKeys {
+				name = snakeToCamel(key)
+			}
+			encoded, _ := json.Marshal(name)
+			out.Write(encoded)
+			out.WriteByte(':')
+			if err := camelCaseValue(dec, out, !dataMapFields[key]); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case '[':
+		out.WriteByte('[')
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := camelCaseValue(dec, out, true); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	}
+	// Consume the closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+// snakeToCamel turns "total_amount_value" into "totalAmountValue".
+func snakeToCamel(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
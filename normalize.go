@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+// entityAmount returns an entity's monetary value and currency, preferring
+// Document AI's normalized money value over parsing the mention text, which
+// is read with the separator conventions of expectedCurrency.
+func entityAmount(entity *documentaipb.Document_Entity, expectedCurrency string) (float64, string, bool) {
+	if money := entity.GetNormalizedValue().GetMoneyValue(); money != nil {
+		amount := float64(money.Units) + float64(money.Nanos)/1e9
+		return amount, money.CurrencyCode, true
+	}
+	amount, ok := parseAmount(disambiguateAmounts(entity.MentionText, expectedCurrency))
+	return amount, "", ok
+}
+
+// entityDate returns an entity's date as YYYY-MM-DD and, when present, its
+// time as HH:MM:SS, preferring Document AI's normalized value over parsing
+// the mention text.
+func entityDate(entity *documentaipb.Document_Entity) (string, string) {
+	normalized := entity.GetNormalizedValue()
+	if date := normalized.GetDateValue(); date != nil && date.Year > 0 && date.Month > 0 && date.Day > 0 {
+		_, timeOfDay := normalizeDate(entity.MentionText)
+		return fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day), timeOfDay
+	}
+	if datetime := normalized.GetDatetimeValue(); datetime != nil && datetime.Year > 0 && datetime.Month > 0 && datetime.Day > 0 {
+		return fmt.Sprintf("%04d-%02d-%02d", datetime.Year, datetime.Month, datetime.Day),
+			fmt.Sprintf("%02d:%02d:%02d", datetime.Hours, datetime.Minutes, datetime.Seconds)
+	}
+	return normalizeDate(entity.MentionText)
+}
+
+var (
+	isoDateRegex      = regexp.MustCompile(`\b(\d{4})[-./](\d{1,2})[-./](\d{1,2})\b`)
+	dayFirstDateRegex = regexp.MustCompile(`\b(\d{1,2})[-./](\d{1,2})[-./](\d{2,4})\b`)
+	timeRegex         = regexp.MustCompile(`\b([01]?\d|2[0-3]):([0-5]\d)(?::([0-5]\d))?\b`)
+)
+
+// normalizeDate converts the common receipt date layouts (2024-03-12,
+// 12.03.2024, 12/03/24, ...) to YYYY-MM-DD, and any time of day printed
+// alongside it ("12.03.2024 14:32") to HH:MM:SS. Ambiguous numeric dates are
+// read day-first, as printed on European receipts. Either result is "" when
+// not found.
+func normalizeDate(s string) (string, string) {
+	return normalizeDateOnly(s), normalizeTime(s)
+}
+
+// normalizeTime returns the first time of day in s as HH:MM:SS.
+func normalizeTime(s string) string {
+	match := timeRegex.FindStringSubmatch(s)
+	if match == nil {
+		return ""
+	}
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.Atoi(match[3])
+	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+}
+
+func normalizeDateOnly(s string) string {
+	var year, month, day int
+	if match := isoDateRegex.FindStringSubmatch(s); match != nil {
+		year, _ = strconv.Atoi(match[1])
+		month, _ = strconv.Atoi(match[2])
+		day, _ = strconv.Atoi(match[3])
+	} else if match := dayFirstDateRegex.FindStringSubmatch(s); match != nil {
+		day, _ = strconv.Atoi(match[1])
+		month, _ = strconv.Atoi(match[2])
+		year, _ = strconv.Atoi(match[3])
+		if year < 100 {
+			year += 2000
+		}
+		// Fall back to month-first when day-first can't be valid (US layout)
+		if month > 12 && day <= 12 {
+			day, month = month, day
+		}
+	} else {
+		return ""
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Year() != year || int(date.Month()) != month || date.Day() != day {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
@@ -0,0 +1,39 @@
+package main
+
+import "cloud.google.com/go/documentai/apiv1/documentaipb"
+
+// PageOrientation is the way a page's text reads, as detected by Document
+// AI. Bounding boxes are relative to the page as uploaded, so overlays drawn
+// on a rotated scan must be rotated by the same amount as the image.
+type PageOrientation struct {
+	Page        int    `json:"page"`
+	Orientation string `json:"orientation"`
+	// Rotation is the clockwise rotation in degrees that makes the page
+	// upright: 0, 90, 180 or 270
+	Rotation int `json:"rotation"`
+}
+
+var pageOrientations = map[documentaipb.Document_Page_Layout_Orientation]PageOrientation{
+	documentaipb.Document_Page_Layout_PAGE_UP:    {Orientation: "up", Rotation: 0},
+	documentaipb.Document_Page_Layout_PAGE_RIGHT: {Orientation: "right", Rotation: 270},
+	documentaipb.Document_Page_Layout_PAGE_DOWN:  {Orientation: "down", Rotation: 180},
+	documentaipb.Document_Page_Layout_PAGE_LEFT:  {Orientation: "left", Rotation: 90},
+}
+
+// collectPageOrientations returns the orientation of each page that has one.
+// Pages are numbered from 1.
+func collectPageOrientations(pages []*documentaipb.Document_Page) []PageOrientation {
+	var orientations []PageOrientation
+	for i, page := range pages {
+		if page.Layout == nil {
+			continue
+		}
+		orientation, ok := pageOrientations[page.Layout.Orientation]
+		if !ok {
+			continue
+		}
+		orientation.Page = i + 1
+		orientations = append(orientations, orientation)
+	}
+	return orientations
+}
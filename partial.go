@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// partialResultHeader marks a response built from only part of the input,
+// since 206 Partial Content is reserved for range requests.
+const partialResultHeader = "X-Partial-Result"
+
+type softDeadlineKey struct{}
+
+// partialResultsEnabled reports whether PARTIAL_RESULTS=true, which lets a
+// request stop at a soft deadline and return what it has so far.
+func partialResultsEnabled() bool {
+	return os.Getenv("PARTIAL_RESULTS") == "true"
+}
+
+// softDeadline returns how long a synchronous request may run before
+// partial results are returned: the X-Response-Timeout header in seconds,
+// else SOFT_DEADLINE. It is 0 when partial results are disabled or neither
+// is set.
+func softDeadline(r *http.Request) (time.Duration, error) {
+	if !partialResultsEnabled() {
+		return 0, nil
+	}
+	header := strings.TrimSpace(r.Header.Get("X-Response-Timeout"))
+	if header == "" {
+		return durationFromEnv("SOFT_DEADLINE", 0), nil
+	}
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("invalid X-Response-Timeout %q: must be a positive number of seconds", header)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func withSoftDeadline(ctx context.Context, deadline time.Time) context.Context {
+	return context.WithValue(ctx, softDeadlineKey{}, deadline)
+}
+
+func softDeadlineFrom(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Value(softDeadlineKey{}).(time.Time)
+	return deadline, ok
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// softDeadlineRecorder records whether Document AI calls carried a soft
+// deadline.
+type softDeadlineRecorder struct {
+	*fakeDocumentProcessor
+	sawSoftDeadline bool
+}
+
+func (p *softDeadlineRecorder) ProcessDocument(ctx context.Context, req *documentaipb.ProcessRequest, opts ...gax.CallOption) (*documentaipb.ProcessResponse, error) {
+	if _, ok := softDeadlineFrom(ctx); ok {
+		p.sawSoftDeadline = true
+	}
+	return p.fakeDocumentProcessor.ProcessDocument(ctx, req, opts...)
+}
+
+func TestSoftDeadlineIgnoredForSingleImage(t *testing.T) {
+	t.Setenv("PARTIAL_RESULTS", "true")
+	backend := &softDeadlineRecorder{fakeDocumentProcessor: &fakeDocumentProcessor{document: testReceiptDocument()}}
+	installFakeProcessor(t, backend)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/ocr", strings.NewReader(ocrRequestBody(t, testPNG(t, 30))))
+	r.Header.Set("X-Response-Timeout", "1")
+	w := httptest.NewRecorder()
+	handleOCR(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", w.Code, http.StatusOK, w.Body)
+	}
+	if backend.sawSoftDeadline {
+		t.Error("single-image request ran with a soft deadline")
+	}
+	if w.Header().Get(partialResultHeader) != "" {
+		t.Errorf("%s set on a single-image response", partialResultHeader)
+	}
+}
+
+func TestSoftDeadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled string
+		header  string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "disabled", enabled: "false", header: "5", want: "0s"},
+		{name: "header", enabled: "true", header: "5", env: "9", want: "5s"},
+		{name: "env fallback", enabled: "true", env: "9", want: "9s"},
+		{name: "malformed header", enabled: "true", header: "soon", wantErr: true},
+		{name: "zero header", enabled: "true", header: "0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PARTIAL_RESULTS", tt.enabled)
+			t.Setenv("SOFT_DEADLINE", tt.env)
+			r := httptest.NewRequest(http.MethodPost, "/api/ocr", nil)
+			if tt.header != "" {
+				r.Header.Set("X-Response-Timeout", tt.header)
+			}
+			got, err := softDeadline(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("softDeadline error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("softDeadline = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
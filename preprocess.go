@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"sort"
+	"strings"
+)
+
+// imageFilter is one preprocessing step. Filters take and return decoded
+// images so they can be chained without re-encoding in between.
+type imageFilter func(image.Image) image.Image
+
+// imageFilters are the filters preprocess_filters may name.
+var imageFilters = map[string]imageFilter{
+	"grayscale": grayscale,
+	"contrast":  stretchContrast,
+}
+
+// contrastClip is the share of the darkest and of the brightest pixels
+// stretchContrast ignores, so a few specks don't pin the range.
+const contrastClip = 0.01
+
+func validatePreprocessFilters(names []string) error {
+	for _, name := range names {
+		if _, ok := imageFilters[name]; !ok {
+			known := make([]string, 0, len(imageFilters))
+			for filter := range imageFilters {
+				known = append(known, filter)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("unknown preprocess filter %q: supported filters are %s", name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// preprocessImage applies the named filters in order to a JPEG or PNG and
+// re-encodes it in the same format. PDFs and TIFFs are returned untouched.
+func preprocessImage(data []byte, mimeType string, names []string) ([]byte, error) {
+	if len(names) == 0 || (mimeType != "image/jpeg" && mimeType != "image/png") {
+		return data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+	for _, name := range names {
+		img = imageFilters[name](img)
+	}
+
+	var buf bytes.Buffer
+	if mimeType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode preprocessed image: %v", err)
+	}
+	logDebugf("Preprocessed image with %s, %d -> %d bytes", strings.Join(names, ", "), len(data), buf.Len())
+	return buf.Bytes(), nil
+}
+
+// grayscale converts img to 8-bit luminance.
+func grayscale(img image.Image) image.Image {
+	bounds := img.Bounds()
+	gray := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray.Set(x, y, color.GrayModel.Convert(img.At(x, y)))
+		}
+	}
+	return gray
+}
+
+// stretchContrast maps the luminance range of img, less contrastClip at
+// either end, onto the full 0-255 range, so faded thermal print becomes
+// dark on white. Colour is stretched per channel by the same amount. Images
+// that are already a single flat tone are returned as they are.
+func stretchContrast(img image.Image) image.Image {
+	bounds := img.Bounds()
+	var histogram [256]int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			histogram[color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y]++
+		}
+	}
+
+	clip := int(float64(bounds.Dx()*bounds.Dy()) * contrastClip)
+	low, high := 0, 255
+	for seen := histogram[low]; seen <= clip && low < 255; seen += histogram[low] {
+		low++
+	}
+	for seen := histogram[high]; seen <= clip && high > 0; seen += histogram[high] {
+		high--
+	}
+	if high <= low {
+		return img
+	}
+
+	var lookup [256]uint8
+	for v := range lookup {
+		stretched := (v - low) * 255 / (high - low)
+		lookup[v] = uint8(min(max(stretched, 0), 255))
+	}
+
+	if gray, ok := img.(*image.Gray); ok {
+		out := image.NewGray(bounds)
+		for i, v := range gray.Pix {
+			out.Pix[i] = lookup[v]
+		}
+		return out
+	}
+	out := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			out.Set(x, y, color.RGBA{lookup[r>>8], lookup[g>>8], lookup[b>>8], uint8(a >> 8)})
+		}
+	}
+	return out
+}
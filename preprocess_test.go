@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestValidatePreprocessFilters(t *testing.T) {
+	tests := []struct {
+		filters []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"grayscale"}, false},
+		{[]string{"grayscale", "contrast"}, false},
+		{[]string{"sharpen"}, true},
+		{[]string{"grayscale", "Contrast"}, true},
+	}
+	for _, tt := range tests {
+		if err := validatePreprocessFilters(tt.filters); (err != nil) != tt.wantErr {
+			t.Errorf("validatePreprocessFilters(%q) = %v, want error %v", tt.filters, err, tt.wantErr)
+		}
+	}
+}
+
+// grayStrip returns a one-pixel-high image with the given luminances.
+func grayStrip(values ...uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(values), 1))
+	copy(img.Pix, values)
+	return img
+}
+
+// fadedGray is a strip with luminance rising from 100 to 150, like faded
+// thermal print.
+func fadedGray() *image.Gray {
+	values := make([]uint8, 10)
+	for x := range values {
+		values[x] = uint8(100 + x*50/9)
+	}
+	return grayStrip(values...)
+}
+
+func TestStretchContrast(t *testing.T) {
+	tests := []struct {
+		name  string
+		img   *image.Gray
+		first uint8
+		last  uint8
+	}{
+		{"faded gray", fadedGray(), 0, 255},
+		{"full range already", grayStrip(0, 255), 0, 255},
+		{"flat tone is left alone", grayStrip(128, 128, 128, 128), 128, 128},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := stretchContrast(tt.img).(*image.Gray)
+			if first, last := out.Pix[0], out.Pix[len(out.Pix)-1]; first != tt.first || last != tt.last {
+				t.Errorf("stretched range = %d..%d, want %d..%d", first, last, tt.first, tt.last)
+			}
+		})
+	}
+}
+
+func TestGrayscale(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	out := grayscale(img)
+	gray, ok := out.(*image.Gray)
+	if !ok {
+		t.Fatalf("grayscale returned %T, want *image.Gray", out)
+	}
+	if want := color.GrayModel.Convert(color.RGBA{R: 255, A: 255}).(color.Gray).Y; gray.Pix[0] != want {
+		t.Errorf("luminance = %d, want %d", gray.Pix[0], want)
+	}
+}
+
+func TestPreprocessImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, fadedGray()); err != nil {
+		t.Fatal(err)
+	}
+	pngData := buf.Bytes()
+	pdfData := []byte("%PDF-1.7 not an image")
+
+	tests := []struct {
+		name      string
+		data      []byte
+		mimeType  string
+		filters   []string
+		unchanged bool
+	}{
+		{"no filters", pngData, "image/png", nil, true},
+		{"PDF is passed through", pdfData, "application/pdf", []string{"contrast"}, true},
+		{"PNG is filtered", pngData, "image/png", []string{"grayscale", "contrast"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := preprocessImage(tt.data, tt.mimeType, tt.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Equal(out, tt.data) != tt.unchanged {
+				t.Fatalf("output unchanged = %v, want %v", bytes.Equal(out, tt.data), tt.unchanged)
+			}
+			if tt.unchanged {
+				return
+			}
+			if mimeType, err := detectMimeType(out); err != nil || mimeType != tt.mimeType {
+				t.Errorf("output format = %q, %v, want %s", mimeType, err, tt.mimeType)
+			}
+		})
+	}
+}
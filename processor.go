@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// documentProcessor is the part of the Document AI client processDocument
+// uses, so the backend can be replaced without Google credentials.
+type documentProcessor interface {
+	ProcessDocument(ctx context.Context, req *documentaipb.ProcessRequest, opts ...gax.CallOption) (*documentaipb.ProcessResponse, error)
+	Close() error
+}
+
+// newDocumentProcessor creates the backend, which processDocument shares
+// through documentAIClient. It returns a fake when DOCUMENT_AI_FAKE_RESPONSE
+// or DOCUMENT_AI_FAKE_ERROR is set and can be swapped out entirely.
+var newDocumentProcessor = func(ctx context.Context) (documentProcessor, error) {
+	if os.Getenv("DOCUMENT_AI_FAKE_RESPONSE") != "" || os.Getenv("DOCUMENT_AI_FAKE_ERROR") != "" {
+		return loadFakeDocumentProcessor()
+	}
+	client, err := newDocumentAIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// documentAIRetry retries Unavailable and DeadlineExceeded errors with
+// exponential backoff until the call's deadline, as the client library does
+// by default for ProcessDocument. It is passed explicitly so the fake
+// honors it too.
+func documentAIRetry() gax.CallOption {
+	return gax.WithRetry(func() gax.Retryer {
+		return gax.OnCodes([]codes.Code{codes.DeadlineExceeded, codes.Unavailable}, gax.Backoff{
+			Initial:    100 * time.Millisecond,
+			Max:        60 * time.Second,
+			Multiplier: 1.30,
+		})
+	})
+}
+
+// fakeDocumentProcessor answers every request with a canned Document, or
+// with err when set (e.g. a gRPC Unavailable status to simulate an outage).
+// With failCalls set, only the first failCalls calls fail, so a transient
+// error can be followed by a success. Retry options are honored like the
+// real client does.
+type fakeDocumentProcessor struct {
+	document  *documentaipb.Document
+	err       error
+	failCalls int
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeDocumentProcessor) ProcessDocument(ctx context.Context, req *documentaipb.ProcessRequest, opts ...gax.CallOption) (*documentaipb.ProcessResponse, error) {
+	var response *documentaipb.ProcessResponse
+	err := gax.Invoke(ctx, func(ctx context.Context, _ gax.CallSettings) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f.mu.Lock()
+		f.calls++
+		calls := f.calls
+		f.mu.Unlock()
+		if f.err != nil && (f.failCalls == 0 || calls <= f.failCalls) {
+			return f.err
+		}
+		response = &documentaipb.ProcessResponse{Document: f.document}
+		return nil
+	}, opts...)
+	return response, err
+}
+
+// Calls returns how many calls reached the fake, retries included.
+func (f *fakeDocumentProcessor) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *fakeDocumentProcessor) Close() error {
+	return nil
+}
+
+// loadFakeDocumentProcessor builds the fake from the environment: the
+// Document in DOCUMENT_AI_FAKE_RESPONSE, in its protobuf JSON form as saved
+// from a real Document AI response, and optionally the gRPC code in
+// DOCUMENT_AI_FAKE_ERROR (e.g. "UNAVAILABLE") returned by every call, or by
+// the first DOCUMENT_AI_FAKE_ERROR_CALLS calls only.
+func loadFakeDocumentProcessor() (*fakeDocumentProcessor, error) {
+	fake := &fakeDocumentProcessor{document: &documentaipb.Document{}}
+	if path := os.Getenv("DOCUMENT_AI_FAKE_RESPONSE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fake Document AI response: %v", err)
+		}
+		if err := protojson.Unmarshal(data, fake.document); err != nil {
+			return nil, fmt.Errorf("failed to parse fake Document AI response %s: %v", path, err)
+		}
+	}
+	if name := os.Getenv("DOCUMENT_AI_FAKE_ERROR"); name != "" {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(name)))); err != nil || code == codes.OK {
+			return nil, fmt.Errorf("invalid DOCUMENT_AI_FAKE_ERROR %q: must be a gRPC status code such as UNAVAILABLE", name)
+		}
+		fake.err = status.Errorf(code, "fake Document AI error")
+		fake.failCalls = intFromEnv("DOCUMENT_AI_FAKE_ERROR_CALLS", 0)
+	}
+	return fake, nil
+}
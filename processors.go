@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type processorRoute struct {
+	keyword     string
+	processorID string
+}
+
+// processorRoutes maps instruction keywords to Document AI processor IDs,
+// longest keyword first so more specific keywords win.
+var processorRoutes []processorRoute
+
+// loadProcessorMap parses PROCESSOR_MAP, a JSON object such as
+// {"restaurant": "abc123", "shop receipt": "def456"}.
+func loadProcessorMap() error {
+	raw := os.Getenv("PROCESSOR_MAP")
+	if raw == "" {
+		return nil
+	}
+
+	var mapping map[string]string
+	if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+		return fmt.Errorf("invalid PROCESSOR_MAP: %v", err)
+	}
+
+	routes := make([]processorRoute, 0, len(mapping))
+	for keyword, processorID := range mapping {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" || processorID == "" {
+			return fmt.Errorf("invalid PROCESSOR_MAP: keywords and processor IDs must not be empty")
+		}
+		routes = append(routes, processorRoute{keyword: keyword, processorID: processorID})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if len(routes[i].keyword) != len(routes[j].keyword) {
+			return len(routes[i].keyword) > len(routes[j].keyword)
+		}
+		return routes[i].keyword < routes[j].keyword
+	})
+
+	processorRoutes = routes
+	return nil
+}
+
+// selectProcessorID picks the processor whose keyword appears in the
+// instructions, falling back to DOCUMENT_AI_PROCESSOR_ID.
+func selectProcessorID(instructions string) string {
+	lower := strings.ToLower(instructions)
+	for _, route := range processorRoutes {
+		if strings.Contains(lower, route.keyword) {
+			return route.processorID
+		}
+	}
+	return os.Getenv("DOCUMENT_AI_PROCESSOR_ID")
+}
@@ -0,0 +1,170 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative receiptpb/receipt.proto
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jakubsoad/receipt-ocr-service/receiptpb"
+	"google.golang.org/protobuf/proto"
+)
+
+const protobufContentType = "application/x-protobuf"
+
+// wantsProtobuf reports whether the client asked for a protobuf response.
+// JSON stays the default for everything else.
+func wantsProtobuf(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accepted, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), protobufContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// marshalProtobufResponse encodes a response as a receiptpb.OCRResponse.
+// Debug output is JSON-only and not included.
+func marshalProtobufResponse(response OCRResponse) ([]byte, error) {
+	message := &receiptpb.OCRResponse{
+		Success:        response.Success,
+		Text:           response.Text,
+		Error:          response.Error,
+		Code:           response.Code,
+		Blocks:         textBlocksToProto(response.Blocks),
+		NoTextDetected: response.NoTextDetected,
+		Partial:        response.Partial,
+	}
+	for _, word := range response.Words {
+		message.Words = append(message.Words, &receiptpb.WordConfidence{
+			Page:       int32(word.Page),
+			Text:       word.Text,
+			Confidence: word.Confidence,
+		})
+	}
+	if summary := response.WordConfidence; summary != nil {
+		message.WordConfidence = &receiptpb.WordConfidenceSummary{
+			WordCount:              int32(summary.WordCount),
+			MeanConfidence:         summary.MeanConfidence,
+			LowConfidenceWords:     int32(summary.LowConfidenceWords),
+			LowConfidenceThreshold: summary.LowConfidenceThreshold,
+		}
+	}
+	if response.Receipt != nil {
+		message.Receipt = receiptToProto(response.Receipt)
+	}
+	return proto.Marshal(message)
+}
+
+func receiptToProto(receipt *Receipt) *receiptpb.Receipt {
+	message := &receiptpb.Receipt{
+		MerchantName:           receipt.MerchantName,
+		MerchantNameSource:     receipt.MerchantNameSource,
+		MerchantNameNormalized: receipt.MerchantNameNormalized,
+		VendorId:               receipt.VendorID,
+		MatchScore:             receipt.MatchScore,
+		MerchantAddress:        receipt.MerchantAddress,
+		MerchantPhone:          receipt.MerchantPhone,
+		TransactionNumber:      receipt.TransactionNumber,
+		Cashier:                receipt.Cashier,
+		FiscalNumber:           receipt.FiscalNumber,
+		LoyaltyCard:            receipt.LoyaltyCard,
+		Date:                   receipt.Date,
+		NormalizedDate:         receipt.NormalizedDate,
+		NormalizedTime:         receipt.NormalizedTime,
+		DateSource:             receipt.DateSource,
+		ExifTimestamp:          receipt.ExifTimestamp,
+		TotalAmount:            receipt.TotalAmount,
+		TotalAmountSource:      receipt.TotalAmountSource,
+		TotalAmountValue:       receipt.TotalAmountValue,
+		TotalAmountMinor:       receipt.TotalAmountMinor,
+		IsRefund:               receipt.IsRefund,
+		FormattedTotal:         receipt.FormattedTotal,
+		Currency:               receipt.Currency,
+		CurrencySource:         receipt.CurrencySource,
+		ImageHash:              receipt.ImageHash,
+		DuplicateSuspected:     receipt.DuplicateSuspected,
+		Subtotal:               receipt.Subtotal,
+		Tax:                    receipt.Tax,
+		Tip:                    receipt.Tip,
+		SubtotalMinor:          receipt.SubtotalMinor,
+		TaxMinor:               receipt.TaxMinor,
+		TipMinor:               receipt.TipMinor,
+		TotalsReconcile:        receipt.TotalsReconcile,
+		TotalsDiscrepancy:      receipt.TotalsDiscrepancy,
+		ItemCount:              int32(receipt.ItemCount),
+		ItemsPriceSum:          receipt.ItemsPriceSum,
+		ItemsPriceSumMinor:     receipt.ItemsPriceSumMinor,
+		ItemsDiscrepancy:       receipt.ItemsDiscrepancy,
+		Truncated:              receipt.Truncated,
+		FilteredItemCount:      int32(receipt.FilteredItemCount),
+		LowConfidenceFields:    receipt.LowConfidenceFields,
+	}
+	for _, language := range receipt.DetectedLanguages {
+		message.DetectedLanguages = append(message.DetectedLanguages, &receiptpb.DetectedLanguage{
+			Code:       language.Code,
+			Confidence: language.Confidence,
+		})
+	}
+	for _, barcode := range receipt.Barcodes {
+		message.Barcodes = append(message.Barcodes, &receiptpb.Barcode{
+			Page:        int32(barcode.Page),
+			Format:      barcode.Format,
+			ValueFormat: barcode.ValueFormat,
+			Value:       barcode.Value,
+		})
+	}
+	for _, orientation := range receipt.PageOrientations {
+		message.PageOrientations = append(message.PageOrientations, &receiptpb.PageOrientation{
+			Page:        int32(orientation.Page),
+			Orientation: orientation.Orientation,
+			Rotation:    int32(orientation.Rotation),
+		})
+	}
+	for _, item := range receipt.Items {
+		message.Items = append(message.Items, &receiptpb.ReceiptItem{
+			Description:        item.Description,
+			Quantity:           item.Quantity,
+			Unit:               item.Unit,
+			Price:              item.Price,
+			TotalPrice:         item.TotalPrice,
+			Computed:           item.Computed,
+			UnitPrice:          item.UnitPrice,
+			ProductCode:        item.ProductCode,
+			Extra:              item.Extra,
+			Source:             item.Source,
+			Confidence:         item.Confidence,
+			PropertyConfidence: item.PropertyConfidence,
+			Id:                 item.ID,
+			RawDescription:     item.RawDescription,
+			TaxRate:            item.TaxRate,
+			Index:              int32(item.Index),
+			Page:               int32(item.Page),
+		})
+	}
+	for _, field := range receipt.Fields {
+		message.Fields = append(message.Fields, &receiptpb.ReceiptField{
+			Name:       field.Name,
+			Confidence: field.Confidence,
+			Value:      field.Value,
+		})
+	}
+	return message
+}
+
+func textBlocksToProto(blocks []TextBlock) []*receiptpb.TextBlock {
+	var messages []*receiptpb.TextBlock
+	for _, block := range blocks {
+		message := &receiptpb.TextBlock{
+			Page:       int32(block.Page),
+			Text:       block.Text,
+			Confidence: block.Confidence,
+		}
+		for _, vertex := range block.BoundingBox {
+			message.BoundingBox = append(message.BoundingBox, &receiptpb.Vertex{X: vertex.X, Y: vertex.Y})
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
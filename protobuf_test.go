@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jakubsoad/receipt-ocr-service/receiptpb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// populate sets every exported field reachable from v to a non-zero value,
+// so a field missing from the protobuf mapping shows up as a missing key.
+func populate(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("value")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		v.SetInt(2)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		populate(v.Elem())
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		populate(v.Index(0))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		value := reflect.New(v.Type().Elem()).Elem()
+		populate(value)
+		v.SetMapIndex(reflect.ValueOf("key"), value)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			// Debug output is JSON-only
+			if field := v.Type().Field(i); field.IsExported() && field.Name != "Debug" {
+				populate(v.Field(i))
+			}
+		}
+	}
+}
+
+// normalizeProtoJSON turns the strings protojson uses for 64-bit integers
+// back into numbers wherever the JSON response has a number.
+func normalizeProtoJSON(protoValue, jsonValue interface{}) interface{} {
+	switch p := protoValue.(type) {
+	case string:
+		if _, ok := jsonValue.(float64); ok {
+			if n, err := strconv.ParseFloat(p, 64); err == nil {
+				return n
+			}
+		}
+	case map[string]interface{}:
+		j, _ := jsonValue.(map[string]interface{})
+		for key, value := range p {
+			p[key] = normalizeProtoJSON(value, j[key])
+		}
+	case []interface{}:
+		j, _ := jsonValue.([]interface{})
+		for i, value := range p {
+			if i < len(j) {
+				p[i] = normalizeProtoJSON(value, j[i])
+			}
+		}
+	}
+	return protoValue
+}
+
+func TestProtobufMatchesJSON(t *testing.T) {
+	var response OCRResponse
+	populate(reflect.ValueOf(&response).Elem())
+
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON map[string]interface{}
+	if err := json.Unmarshal(encoded, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	wire, err := marshalProtobufResponse(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var message receiptpb.OCRResponse
+	if err := proto.Unmarshal(wire, &message); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(&message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromProto map[string]interface{}
+	if err := json.Unmarshal(encoded, &fromProto); err != nil {
+		t.Fatal(err)
+	}
+	normalizeProtoJSON(fromProto, fromJSON)
+
+	if !reflect.DeepEqual(fromProto, fromJSON) {
+		protoJSON, _ := json.MarshalIndent(fromProto, "", "  ")
+		plainJSON, _ := json.MarshalIndent(fromJSON, "", "  ")
+		t.Errorf("protojson output differs from the JSON response\nprotojson: %s\nJSON: %s", protoJSON, plainJSON)
+	}
+}
+
+func TestResponseVersionHeaderForEveryFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		version     int
+		contentType string
+	}{
+		{"JSON", "/api/ocr", "", responseV1, "application/json"},
+		{"JSON v2", "/api/ocr?v=2", "", responseV2, "application/json"},
+		{"protobuf", "/api/ocr", protobufContentType, responseV1, protobufContentType},
+		{"flat", "/api/ocr?format=flat", "", responseV1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			result := &ocrResult{Texts: []string{"MLEKO 3,99"}, Receipt: &Receipt{MerchantName: "BIEDRONKA"}}
+			sendOCRResponse(w, r, result, tt.version)
+			if got := w.Header().Get("X-Response-Version"); got != strconv.Itoa(tt.version) {
+				t.Errorf("X-Response-Version = %q, want %d", got, tt.version)
+			}
+			if tt.contentType != "" && !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) {
+				t.Errorf("Content-Type = %q, want %s", w.Header().Get("Content-Type"), tt.contentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+)
+
+type readinessCheck struct {
+	OK    bool   `json:"ok"`
+	Path  string `json:"path,omitempty"`
+	State string `json:"state,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Ready  bool                      `json:"ready"`
+	Checks map[string]readinessCheck `json:"checks"`
+}
+
+// handleReady reports whether the service can currently serve OCR requests.
+// Unlike the startup checks it never exits, so credentials rotated or
+// removed while running show up here as a 503.
+func handleReady(w http.ResponseWriter, r *http.Request) {
+	response := readinessResponse{
+		Ready: true,
+		Checks: map[string]readinessCheck{
+			"credentials": checkCredentialsFile(),
+		},
+	}
+	if state := documentAIBreaker.State(); state != "" {
+		response.Checks["document_ai"] = readinessCheck{OK: state != breakerOpen, State: state}
+	}
+	for _, check := range response.Checks {
+		if !check.OK {
+			response.Ready = false
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !response.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func checkCredentialsFile() readinessCheck {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	check := readinessCheck{Path: path}
+	if path == "" {
+		check.Error = "GOOGLE_APPLICATION_CREDENTIALS is not set"
+		return check
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	if info.IsDir() {
+		check.Error = "credentials path is a directory"
+		return check
+	}
+	if info.Size() == 0 {
+		check.Error = "credentials file is empty"
+		return check
+	}
+
+	check.OK = true
+	return check
+}
@@ -0,0 +1,1709 @@
+// Protocol Buffers form of the /api/ocr and /api/parse responses, served
+// when a client sends "Accept: application/x-protobuf". Field names match
+// the JSON response keys. protojson with UseProtoNames gives the same keys
+// and values as the JSON response, except that:
+//   - debug is JSON-only and has no field here
+//   - int64 fields, the *_minor amounts, are encoded as strings
+//   - zero values are left out, including those the JSON response always
+//     has, such as success, item_count and confidence
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: receiptpb/receipt.proto
+
+package receiptpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type OCRResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Success        bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Text           []string               `protobuf:"bytes,2,rep,name=text,proto3" json:"text,omitempty"`
+	Error          string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Receipt        *Receipt               `protobuf:"bytes,4,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	Blocks         []*TextBlock           `protobuf:"bytes,5,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	Words          []*WordConfidence      `protobuf:"bytes,6,rep,name=words,proto3" json:"words,omitempty"`
+	WordConfidence *WordConfidenceSummary `protobuf:"bytes,7,opt,name=word_confidence,json=wordConfidence,proto3" json:"word_confidence,omitempty"`
+	NoTextDetected bool                   `protobuf:"varint,8,opt,name=no_text_detected,json=noTextDetected,proto3" json:"no_text_detected,omitempty"`
+	Partial        bool                   `protobuf:"varint,9,opt,name=partial,proto3" json:"partial,omitempty"`
+	Code           string                 `protobuf:"bytes,10,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+func (x *OCRResponse) Reset() {
+	*x = OCRResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OCRResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OCRResponse) ProtoMessage() {}
+
+func (x *OCRResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OCRResponse.ProtoReflect.Descriptor instead.
+func (*OCRResponse) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OCRResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *OCRResponse) GetText() []string {
+	if x != nil {
+		return x.Text
+	}
+	return nil
+}
+
+func (x *OCRResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *OCRResponse) GetReceipt() *Receipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+func (x *OCRResponse) GetBlocks() []*TextBlock {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+func (x *OCRResponse) GetWords() []*WordConfidence {
+	if x != nil {
+		return x.Words
+	}
+	return nil
+}
+
+func (x *OCRResponse) GetWordConfidence() *WordConfidenceSummary {
+	if x != nil {
+		return x.WordConfidence
+	}
+	return nil
+}
+
+func (x *OCRResponse) GetNoTextDetected() bool {
+	if x != nil {
+		return x.NoTextDetected
+	}
+	return false
+}
+
+func (x *OCRResponse) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *OCRResponse) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type Receipt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MerchantName           string              `protobuf:"bytes,1,opt,name=merchant_name,json=merchantName,proto3" json:"merchant_name,omitempty"`
+	MerchantNameSource     string              `protobuf:"bytes,2,opt,name=merchant_name_source,json=merchantNameSource,proto3" json:"merchant_name_source,omitempty"`
+	MerchantNameNormalized string              `protobuf:"bytes,3,opt,name=merchant_name_normalized,json=merchantNameNormalized,proto3" json:"merchant_name_normalized,omitempty"`
+	MerchantAddress        string              `protobuf:"bytes,4,opt,name=merchant_address,json=merchantAddress,proto3" json:"merchant_address,omitempty"`
+	MerchantPhone          string              `protobuf:"bytes,5,opt,name=merchant_phone,json=merchantPhone,proto3" json:"merchant_phone,omitempty"`
+	TransactionNumber      string              `protobuf:"bytes,6,opt,name=transaction_number,json=transactionNumber,proto3" json:"transaction_number,omitempty"`
+	Cashier                string              `protobuf:"bytes,7,opt,name=cashier,proto3" json:"cashier,omitempty"`
+	Date                   string              `protobuf:"bytes,8,opt,name=date,proto3" json:"date,omitempty"`
+	NormalizedDate         string              `protobuf:"bytes,9,opt,name=normalized_date,json=normalizedDate,proto3" json:"normalized_date,omitempty"`
+	NormalizedTime         string              `protobuf:"bytes,10,opt,name=normalized_time,json=normalizedTime,proto3" json:"normalized_time,omitempty"`
+	DateSource             string              `protobuf:"bytes,11,opt,name=date_source,json=dateSource,proto3" json:"date_source,omitempty"`
+	ExifTimestamp          string              `protobuf:"bytes,12,opt,name=exif_timestamp,json=exifTimestamp,proto3" json:"exif_timestamp,omitempty"`
+	TotalAmount            string              `protobuf:"bytes,13,opt,name=total_amount,json=totalAmount,proto3" json:"total_amount,omitempty"`
+	TotalAmountSource      string              `protobuf:"bytes,14,opt,name=total_amount_source,json=totalAmountSource,proto3" json:"total_amount_source,omitempty"`
+	TotalAmountValue       float64             `protobuf:"fixed64,15,opt,name=total_amount_value,json=totalAmountValue,proto3" json:"total_amount_value,omitempty"`
+	IsRefund               bool                `protobuf:"varint,16,opt,name=is_refund,json=isRefund,proto3" json:"is_refund,omitempty"`
+	FormattedTotal         string              `protobuf:"bytes,17,opt,name=formatted_total,json=formattedTotal,proto3" json:"formatted_total,omitempty"`
+	Currency               string              `protobuf:"bytes,18,opt,name=currency,proto3" json:"currency,omitempty"`
+	CurrencySource         string              `protobuf:"bytes,19,opt,name=currency_source,json=currencySource,proto3" json:"currency_source,omitempty"`
+	ImageHash              string              `protobuf:"bytes,20,opt,name=image_hash,json=imageHash,proto3" json:"image_hash,omitempty"`
+	DuplicateSuspected     bool                `protobuf:"varint,21,opt,name=duplicate_suspected,json=duplicateSuspected,proto3" json:"duplicate_suspected,omitempty"`
+	DetectedLanguages      []*DetectedLanguage `protobuf:"bytes,22,rep,name=detected_languages,json=detectedLanguages,proto3" json:"detected_languages,omitempty"`
+	Subtotal               float64             `protobuf:"fixed64,23,opt,name=subtotal,proto3" json:"subtotal,omitempty"`
+	Tax                    float64             `protobuf:"fixed64,24,opt,name=tax,proto3" json:"tax,omitempty"`
+	Tip                    float64             `protobuf:"fixed64,25,opt,name=tip,proto3" json:"tip,omitempty"`
+	// Only set when both a subtotal and a total were found
+	TotalsReconcile     *bool              `protobuf:"varint,26,opt,name=totals_reconcile,json=totalsReconcile,proto3,oneof" json:"totals_reconcile,omitempty"`
+	TotalsDiscrepancy   float64            `protobuf:"fixed64,27,opt,name=totals_discrepancy,json=totalsDiscrepancy,proto3" json:"totals_discrepancy,omitempty"`
+	ItemCount           int32              `protobuf:"varint,28,opt,name=item_count,json=itemCount,proto3" json:"item_count,omitempty"`
+	ItemsPriceSum       float64            `protobuf:"fixed64,29,opt,name=items_price_sum,json=itemsPriceSum,proto3" json:"items_price_sum,omitempty"`
+	ItemsDiscrepancy    float64            `protobuf:"fixed64,30,opt,name=items_discrepancy,json=itemsDiscrepancy,proto3" json:"items_discrepancy,omitempty"`
+	Truncated           bool               `protobuf:"varint,33,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	Items               []*ReceiptItem     `protobuf:"bytes,31,rep,name=items,proto3" json:"items,omitempty"`
+	Fields              []*ReceiptField    `protobuf:"bytes,32,rep,name=fields,proto3" json:"fields,omitempty"`
+	LowConfidenceFields []string           `protobuf:"bytes,34,rep,name=low_confidence_fields,json=lowConfidenceFields,proto3" json:"low_confidence_fields,omitempty"`
+	PageOrientations    []*PageOrientation `protobuf:"bytes,35,rep,name=page_orientations,json=pageOrientations,proto3" json:"page_orientations,omitempty"`
+	FiscalNumber        string             `protobuf:"bytes,36,opt,name=fiscal_number,json=fiscalNumber,proto3" json:"fiscal_number,omitempty"`
+	Barcodes            []*Barcode         `protobuf:"bytes,37,rep,name=barcodes,proto3" json:"barcodes,omitempty"`
+	FilteredItemCount   int32              `protobuf:"varint,38,opt,name=filtered_item_count,json=filteredItemCount,proto3" json:"filtered_item_count,omitempty"`
+	VendorId            string             `protobuf:"bytes,39,opt,name=vendor_id,json=vendorId,proto3" json:"vendor_id,omitempty"`
+	MatchScore          float64            `protobuf:"fixed64,40,opt,name=match_score,json=matchScore,proto3" json:"match_score,omitempty"`
+	// Money fields as integer counts of the currency's minor unit
+	TotalAmountMinor   int64  `protobuf:"varint,41,opt,name=total_amount_minor,json=totalAmountMinor,proto3" json:"total_amount_minor,omitempty"`
+	SubtotalMinor      int64  `protobuf:"varint,42,opt,name=subtotal_minor,json=subtotalMinor,proto3" json:"subtotal_minor,omitempty"`
+	TaxMinor           int64  `protobuf:"varint,43,opt,name=tax_minor,json=taxMinor,proto3" json:"tax_minor,omitempty"`
+	TipMinor           int64  `protobuf:"varint,44,opt,name=tip_minor,json=tipMinor,proto3" json:"tip_minor,omitempty"`
+	ItemsPriceSumMinor int64  `protobuf:"varint,45,opt,name=items_price_sum_minor,json=itemsPriceSumMinor,proto3" json:"items_price_sum_minor,omitempty"`
+	LoyaltyCard        string `protobuf:"bytes,46,opt,name=loyalty_card,json=loyaltyCard,proto3" json:"loyalty_card,omitempty"`
+}
+
+func (x *Receipt) Reset() {
+	*x = Receipt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Receipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Receipt) ProtoMessage() {}
+
+func (x *Receipt) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Receipt.ProtoReflect.Descriptor instead.
+func (*Receipt) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Receipt) GetMerchantName() string {
+	if x != nil {
+		return x.MerchantName
+	}
+	return ""
+}
+
+func (x *Receipt) GetMerchantNameSource() string {
+	if x != nil {
+		return x.MerchantNameSource
+	}
+	return ""
+}
+
+func (x *Receipt) GetMerchantNameNormalized() string {
+	if x != nil {
+		return x.MerchantNameNormalized
+	}
+	return ""
+}
+
+func (x *Receipt) GetMerchantAddress() string {
+	if x != nil {
+		return x.MerchantAddress
+	}
+	return ""
+}
+
+func (x *Receipt) GetMerchantPhone() string {
+	if x != nil {
+		return x.MerchantPhone
+	}
+	return ""
+}
+
+func (x *Receipt) GetTransactionNumber() string {
+	if x != nil {
+		return x.TransactionNumber
+	}
+	return ""
+}
+
+func (x *Receipt) GetCashier() string {
+	if x != nil {
+		return x.Cashier
+	}
+	return ""
+}
+
+func (x *Receipt) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *Receipt) GetNormalizedDate() string {
+	if x != nil {
+		return x.NormalizedDate
+	}
+	return ""
+}
+
+func (x *Receipt) GetNormalizedTime() string {
+	if x != nil {
+		return x.NormalizedTime
+	}
+	return ""
+}
+
+func (x *Receipt) GetDateSource() string {
+	if x != nil {
+		return x.DateSource
+	}
+	return ""
+}
+
+func (x *Receipt) GetExifTimestamp() string {
+	if x != nil {
+		return x.ExifTimestamp
+	}
+	return ""
+}
+
+func (x *Receipt) GetTotalAmount() string {
+	if x != nil {
+		return x.TotalAmount
+	}
+	return ""
+}
+
+func (x *Receipt) GetTotalAmountSource() string {
+	if x != nil {
+		return x.TotalAmountSource
+	}
+	return ""
+}
+
+func (x *Receipt) GetTotalAmountValue() float64 {
+	if x != nil {
+		return x.TotalAmountValue
+	}
+	return 0
+}
+
+func (x *Receipt) GetIsRefund() bool {
+	if x != nil {
+		return x.IsRefund
+	}
+	return false
+}
+
+func (x *Receipt) GetFormattedTotal() string {
+	if x != nil {
+		return x.FormattedTotal
+	}
+	return ""
+}
+
+func (x *Receipt) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Receipt) GetCurrencySource() string {
+	if x != nil {
+		return x.CurrencySource
+	}
+	return ""
+}
+
+func (x *Receipt) GetImageHash() string {
+	if x != nil {
+		return x.ImageHash
+	}
+	return ""
+}
+
+func (x *Receipt) GetDuplicateSuspected() bool {
+	if x != nil {
+		return x.DuplicateSuspected
+	}
+	return false
+}
+
+func (x *Receipt) GetDetectedLanguages() []*DetectedLanguage {
+	if x != nil {
+		return x.DetectedLanguages
+	}
+	return nil
+}
+
+func (x *Receipt) GetSubtotal() float64 {
+	if x != nil {
+		return x.Subtotal
+	}
+	return 0
+}
+
+func (x *Receipt) GetTax() float64 {
+	if x != nil {
+		return x.Tax
+	}
+	return 0
+}
+
+func (x *Receipt) GetTip() float64 {
+	if x != nil {
+		return x.Tip
+	}
+	return 0
+}
+
+func (x *Receipt) GetTotalsReconcile() bool {
+	if x != nil && x.TotalsReconcile != nil {
+		return *x.TotalsReconcile
+	}
+	return false
+}
+
+func (x *Receipt) GetTotalsDiscrepancy() float64 {
+	if x != nil {
+		return x.TotalsDiscrepancy
+	}
+	return 0
+}
+
+func (x *Receipt) GetItemCount() int32 {
+	if x != nil {
+		return x.ItemCount
+	}
+	return 0
+}
+
+func (x *Receipt) GetItemsPriceSum() float64 {
+	if x != nil {
+		return x.ItemsPriceSum
+	}
+	return 0
+}
+
+func (x *Receipt) GetItemsDiscrepancy() float64 {
+	if x != nil {
+		return x.ItemsDiscrepancy
+	}
+	return 0
+}
+
+func (x *Receipt) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *Receipt) GetItems() []*ReceiptItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *Receipt) GetFields() []*ReceiptField {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *Receipt) GetLowConfidenceFields() []string {
+	if x != nil {
+		return x.LowConfidenceFields
+	}
+	return nil
+}
+
+func (x *Receipt) GetPageOrientations() []*PageOrientation {
+	if x != nil {
+		return x.PageOrientations
+	}
+	return nil
+}
+
+func (x *Receipt) GetFiscalNumber() string {
+	if x != nil {
+		return x.FiscalNumber
+	}
+	return ""
+}
+
+func (x *Receipt) GetBarcodes() []*Barcode {
+	if x != nil {
+		return x.Barcodes
+	}
+	return nil
+}
+
+func (x *Receipt) GetFilteredItemCount() int32 {
+	if x != nil {
+		return x.FilteredItemCount
+	}
+	return 0
+}
+
+func (x *Receipt) GetVendorId() string {
+	if x != nil {
+		return x.VendorId
+	}
+	return ""
+}
+
+func (x *Receipt) GetMatchScore() float64 {
+	if x != nil {
+		return x.MatchScore
+	}
+	return 0
+}
+
+func (x *Receipt) GetTotalAmountMinor() int64 {
+	if x != nil {
+		return x.TotalAmountMinor
+	}
+	return 0
+}
+
+func (x *Receipt) GetSubtotalMinor() int64 {
+	if x != nil {
+		return x.SubtotalMinor
+	}
+	return 0
+}
+
+func (x *Receipt) GetTaxMinor() int64 {
+	if x != nil {
+		return x.TaxMinor
+	}
+	return 0
+}
+
+func (x *Receipt) GetTipMinor() int64 {
+	if x != nil {
+		return x.TipMinor
+	}
+	return 0
+}
+
+func (x *Receipt) GetItemsPriceSumMinor() int64 {
+	if x != nil {
+		return x.ItemsPriceSumMinor
+	}
+	return 0
+}
+
+func (x *Receipt) GetLoyaltyCard() string {
+	if x != nil {
+		return x.LoyaltyCard
+	}
+	return ""
+}
+
+type ReceiptItem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Description        string             `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
+	Quantity           string             `protobuf:"bytes,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Unit               string             `protobuf:"bytes,3,opt,name=unit,proto3" json:"unit,omitempty"`
+	Price              string             `protobuf:"bytes,4,opt,name=price,proto3" json:"price,omitempty"`
+	TotalPrice         string             `protobuf:"bytes,5,opt,name=total_price,json=totalPrice,proto3" json:"total_price,omitempty"`
+	Computed           bool               `protobuf:"varint,6,opt,name=computed,proto3" json:"computed,omitempty"`
+	UnitPrice          string             `protobuf:"bytes,7,opt,name=unit_price,json=unitPrice,proto3" json:"unit_price,omitempty"`
+	ProductCode        string             `protobuf:"bytes,8,opt,name=product_code,json=productCode,proto3" json:"product_code,omitempty"`
+	Extra              map[string]string  `protobuf:"bytes,9,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Source             string             `protobuf:"bytes,10,opt,name=source,proto3" json:"source,omitempty"`
+	Confidence         float32            `protobuf:"fixed32,11,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	PropertyConfidence map[string]float32 `protobuf:"bytes,12,rep,name=property_confidence,json=propertyConfidence,proto3" json:"property_confidence,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed32,2,opt,name=value,proto3"`
+	Id                 string             `protobuf:"bytes,13,opt,name=id,proto3" json:"id,omitempty"`
+	RawDescription     string             `protobuf:"bytes,14,opt,name=raw_description,json=rawDescription,proto3" json:"raw_description,omitempty"`
+	TaxRate            *float64           `protobuf:"fixed64,15,opt,name=tax_rate,json=taxRate,proto3,oneof" json:"tax_rate,omitempty"`
+	Index              int32              `protobuf:"varint,16,opt,name=index,proto3" json:"index,omitempty"`
+	Page               int32              `protobuf:"varint,17,opt,name=page,proto3" json:"page,omitempty"`
+}
+
+func (x *ReceiptItem) Reset() {
+	*x = ReceiptItem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReceiptItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiptItem) ProtoMessage() {}
+
+func (x *ReceiptItem) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiptItem.ProtoReflect.Descriptor instead.
+func (*ReceiptItem) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReceiptItem) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetTotalPrice() string {
+	if x != nil {
+		return x.TotalPrice
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetComputed() bool {
+	if x != nil {
+		return x.Computed
+	}
+	return false
+}
+
+func (x *ReceiptItem) GetUnitPrice() string {
+	if x != nil {
+		return x.UnitPrice
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetProductCode() string {
+	if x != nil {
+		return x.ProductCode
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+func (x *ReceiptItem) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *ReceiptItem) GetPropertyConfidence() map[string]float32 {
+	if x != nil {
+		return x.PropertyConfidence
+	}
+	return nil
+}
+
+func (x *ReceiptItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetRawDescription() string {
+	if x != nil {
+		return x.RawDescription
+	}
+	return ""
+}
+
+func (x *ReceiptItem) GetTaxRate() float64 {
+	if x != nil && x.TaxRate != nil {
+		return *x.TaxRate
+	}
+	return 0
+}
+
+func (x *ReceiptItem) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ReceiptItem) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+type DetectedLanguage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code       string  `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Confidence float32 `protobuf:"fixed32,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+}
+
+func (x *DetectedLanguage) Reset() {
+	*x = DetectedLanguage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DetectedLanguage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetectedLanguage) ProtoMessage() {}
+
+func (x *DetectedLanguage) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetectedLanguage.ProtoReflect.Descriptor instead.
+func (*DetectedLanguage) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DetectedLanguage) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *DetectedLanguage) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type Barcode struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Page        int32  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Format      string `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	ValueFormat string `protobuf:"bytes,3,opt,name=value_format,json=valueFormat,proto3" json:"value_format,omitempty"`
+	Value       string `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *Barcode) Reset() {
+	*x = Barcode{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Barcode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Barcode) ProtoMessage() {}
+
+func (x *Barcode) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Barcode.ProtoReflect.Descriptor instead.
+func (*Barcode) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Barcode) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *Barcode) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Barcode) GetValueFormat() string {
+	if x != nil {
+		return x.ValueFormat
+	}
+	return ""
+}
+
+func (x *Barcode) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type PageOrientation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Page        int32  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Orientation string `protobuf:"bytes,2,opt,name=orientation,proto3" json:"orientation,omitempty"`
+	Rotation    int32  `protobuf:"varint,3,opt,name=rotation,proto3" json:"rotation,omitempty"`
+}
+
+func (x *PageOrientation) Reset() {
+	*x = PageOrientation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PageOrientation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PageOrientation) ProtoMessage() {}
+
+func (x *PageOrientation) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PageOrientation.ProtoReflect.Descriptor instead.
+func (*PageOrientation) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PageOrientation) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *PageOrientation) GetOrientation() string {
+	if x != nil {
+		return x.Orientation
+	}
+	return ""
+}
+
+func (x *PageOrientation) GetRotation() int32 {
+	if x != nil {
+		return x.Rotation
+	}
+	return 0
+}
+
+type ReceiptField struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name       string  `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Confidence float32 `protobuf:"fixed32,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Value      string  `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *ReceiptField) Reset() {
+	*x = ReceiptField{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReceiptField) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiptField) ProtoMessage() {}
+
+func (x *ReceiptField) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiptField.ProtoReflect.Descriptor instead.
+func (*ReceiptField) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ReceiptField) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ReceiptField) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *ReceiptField) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type TextBlock struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Page        int32     `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Text        string    `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Confidence  float32   `protobuf:"fixed32,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	BoundingBox []*Vertex `protobuf:"bytes,4,rep,name=bounding_box,json=boundingBox,proto3" json:"bounding_box,omitempty"`
+}
+
+func (x *TextBlock) Reset() {
+	*x = TextBlock{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TextBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextBlock) ProtoMessage() {}
+
+func (x *TextBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextBlock.ProtoReflect.Descriptor instead.
+func (*TextBlock) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TextBlock) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *TextBlock) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *TextBlock) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *TextBlock) GetBoundingBox() []*Vertex {
+	if x != nil {
+		return x.BoundingBox
+	}
+	return nil
+}
+
+type Vertex struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X float32 `protobuf:"fixed32,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y float32 `protobuf:"fixed32,2,opt,name=y,proto3" json:"y,omitempty"`
+}
+
+func (x *Vertex) Reset() {
+	*x = Vertex{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Vertex) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Vertex) ProtoMessage() {}
+
+func (x *Vertex) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Vertex.ProtoReflect.Descriptor instead.
+func (*Vertex) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Vertex) GetX() float32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Vertex) GetY() float32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+type WordConfidence struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Page       int32   `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Text       string  `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Confidence float32 `protobuf:"fixed32,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+}
+
+func (x *WordConfidence) Reset() {
+	*x = WordConfidence{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WordConfidence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WordConfidence) ProtoMessage() {}
+
+func (x *WordConfidence) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WordConfidence.ProtoReflect.Descriptor instead.
+func (*WordConfidence) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WordConfidence) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *WordConfidence) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *WordConfidence) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type WordConfidenceSummary struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WordCount              int32   `protobuf:"varint,1,opt,name=word_count,json=wordCount,proto3" json:"word_count,omitempty"`
+	MeanConfidence         float32 `protobuf:"fixed32,2,opt,name=mean_confidence,json=meanConfidence,proto3" json:"mean_confidence,omitempty"`
+	LowConfidenceWords     int32   `protobuf:"varint,3,opt,name=low_confidence_words,json=lowConfidenceWords,proto3" json:"low_confidence_words,omitempty"`
+	LowConfidenceThreshold float32 `protobuf:"fixed32,4,opt,name=low_confidence_threshold,json=lowConfidenceThreshold,proto3" json:"low_confidence_threshold,omitempty"`
+}
+
+func (x *WordConfidenceSummary) Reset() {
+	*x = WordConfidenceSummary{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_receiptpb_receipt_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WordConfidenceSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WordConfidenceSummary) ProtoMessage() {}
+
+func (x *WordConfidenceSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_receiptpb_receipt_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WordConfidenceSummary.ProtoReflect.Descriptor instead.
+func (*WordConfidenceSummary) Descriptor() ([]byte, []int) {
+	return file_receiptpb_receipt_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WordConfidenceSummary) GetWordCount() int32 {
+	if x != nil {
+		return x.WordCount
+	}
+	return 0
+}
+
+func (x *WordConfidenceSummary) GetMeanConfidence() float32 {
+	if x != nil {
+		return x.MeanConfidence
+	}
+	return 0
+}
+
+func (x *WordConfidenceSummary) GetLowConfidenceWords() int32 {
+	if x != nil {
+		return x.LowConfidenceWords
+	}
+	return 0
+}
+
+func (x *WordConfidenceSummary) GetLowConfidenceThreshold() float32 {
+	if x != nil {
+		return x.LowConfidenceThreshold
+	}
+	return 0
+}
+
+var File_receiptpb_receipt_proto protoreflect.FileDescriptor
+
+var file_receiptpb_receipt_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x70, 0x62, 0x2f, 0x72, 0x65, 0x63, 0x65,
+	0x69, 0x70, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x72, 0x65, 0x63, 0x65, 0x69,
+	0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x22, 0x91, 0x03, 0x0a, 0x0b, 0x4f, 0x43, 0x52,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x30, 0x0a, 0x07,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65,
+	0x63, 0x65, 0x69, 0x70, 0x74, 0x52, 0x07, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x12, 0x30,
+	0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x65, 0x78, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73,
+	0x12, 0x33, 0x0a, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1d, 0x2e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x57, 0x6f, 0x72, 0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x05,
+	0x77, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x4d, 0x0a, 0x0f, 0x77, 0x6f, 0x72, 0x64, 0x5f, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24,
+	0x2e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x57,
+	0x6f, 0x72, 0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x53, 0x75, 0x6d,
+	0x6d, 0x61, 0x72, 0x79, 0x52, 0x0e, 0x77, 0x6f, 0x72, 0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64,
+	0x65, 0x6e, 0x63, 0x65, 0x12, 0x28, 0x0a, 0x10, 0x6e, 0x6f, 0x5f, 0x74, 0x65, 0x78, 0x74, 0x5f,
+	0x64, 0x65, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e,
+	0x6e, 0x6f, 0x54, 0x65, 0x78, 0x74, 0x44, 0x65, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x18,
+	0x0a, 0x07, 0x70, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x07, 0x70, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x22, 0xe9, 0x0e, 0x0a,
+	0x07, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x6d, 0x65, 0x72, 0x63,
+	0x68, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x6d, 0x65, 0x72, 0x63, 0x68, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x30, 0x0a,
+	0x14, 0x6d, 0x65, 0x72, 0x63, 0x68, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x6d, 0x65, 0x72,
+	0x63, 0x68, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12,
+	0x38, 0x0a, 0x18, 0x6d, 0x65, 0x72, 0x63, 0x68, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x5f, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x16, 0x6d, 0x65, 0x72, 0x63, 0x68, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x4e,
+	0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x12, 0x29, 0x0a, 0x10, 0x6d, 0x65, 0x72,
+	0x63, 0x68, 0x61, 0x6e, 0x74, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0f, 0x6d, 0x65, 0x72, 0x63, 0x68, 0x61, 0x6e, 0x74, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x6d, 0x65, 0x72, 0x63, 0x68, 0x61, 0x6e, 0x74,
+	0x5f, 0x70, 0x68, 0x6f, 0x6e, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6d, 0x65,
+	0x72, 0x63, 0x68, 0x61, 0x6e, 0x74, 0x50, 0x68, 0x6f, 0x6e, 0x65, 0x12, 0x2d, 0x0a, 0x12, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x61,
+	0x73, 0x68, 0x69, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x61, 0x73,
+	0x68, 0x69, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x64, 0x61, 0x74, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x6e, 0x6f, 0x72, 0x6d,
+	0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0e, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x44, 0x61, 0x74,
+	0x65, 0x12, 0x27, 0x0a, 0x0f, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x6e, 0x6f, 0x72, 0x6d,
+	0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x61,
+	0x74, 0x65, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x64, 0x61, 0x74, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x65,
+	0x78, 0x69, 0x66, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0d, 0x65, 0x78, 0x69, 0x66, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x61, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x41,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x13, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x61,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x11, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x2c, 0x0a, 0x12, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x61,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x56, 0x61,
+	0x6c, 0x75, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x72, 0x65, 0x66, 0x75, 0x6e, 0x64,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69, 0x73, 0x52, 0x65, 0x66, 0x75, 0x6e, 0x64,
+	0x12, 0x27, 0x0a, 0x0f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x66, 0x6f, 0x72, 0x6d, 0x61,
+	0x74, 0x74, 0x65, 0x64, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x27, 0x0a, 0x0f, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63,
+	0x79, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
+	0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x14, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x2f, 0x0a,
+	0x13, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x75, 0x73, 0x70, 0x65,
+	0x63, 0x74, 0x65, 0x64, 0x18, 0x15, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x64, 0x75, 0x70, 0x6c,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x53, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x4e,
+	0x0a, 0x12, 0x64, 0x65, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x6c, 0x61, 0x6e, 0x67, 0x75,
+	0x61, 0x67, 0x65, 0x73, 0x18, 0x16, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x72, 0x65, 0x63,
+	0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x74, 0x65, 0x63,
+	0x74, 0x65, 0x64, 0x4c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x52, 0x11, 0x64, 0x65, 0x74,
+	0x65, 0x63, 0x74, 0x65, 0x64, 0x4c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x73, 0x75, 0x62, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x17, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x08, 0x73, 0x75, 0x62, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61,
+	0x78, 0x18, 0x18, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x74, 0x61, 0x78, 0x12, 0x10, 0x0a, 0x03,
+	0x74, 0x69, 0x70, 0x18, 0x19, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x74, 0x69, 0x70, 0x12, 0x2e,
+	0x0a, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x73, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69,
+	0x6c, 0x65, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x0f, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x73, 0x52, 0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x12, 0x2d,
+	0x0a, 0x12, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x73, 0x5f, 0x64, 0x69, 0x73, 0x63, 0x72, 0x65, 0x70,
+	0x61, 0x6e, 0x63, 0x79, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x73, 0x44, 0x69, 0x73, 0x63, 0x72, 0x65, 0x70, 0x61, 0x6e, 0x63, 0x79, 0x12, 0x1d, 0x0a,
+	0x0a, 0x69, 0x74, 0x65, 0x6d, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x1c, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x09, 0x69, 0x74, 0x65, 0x6d, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x26, 0x0a, 0x0f,
+	0x69, 0x74, 0x65, 0x6d, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x73, 0x75, 0x6d, 0x18,
+	0x1d, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0d, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x50, 0x72, 0x69, 0x63,
+	0x65, 0x53, 0x75, 0x6d, 0x12, 0x2b, 0x0a, 0x11, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x5f, 0x64, 0x69,
+	0x73, 0x63, 0x72, 0x65, 0x70, 0x61, 0x6e, 0x63, 0x79, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x10, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x44, 0x69, 0x73, 0x63, 0x72, 0x65, 0x70, 0x61, 0x6e, 0x63,
+	0x79, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x18, 0x21,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x12,
+	0x30, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x1f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52,
+	0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d,
+	0x73, 0x12, 0x33, 0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x20, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1b, 0x2e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x52, 0x06,
+	0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x32, 0x0a, 0x15, 0x6c, 0x6f, 0x77, 0x5f, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18,
+	0x22, 0x20, 0x03, 0x28, 0x09, 0x52, 0x13, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64,
+	0x65, 0x6e, 0x63, 0x65, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x4b, 0x0a, 0x11, 0x70, 0x61,
+	0x67, 0x65, 0x5f, 0x6f, 0x72, 0x69, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x23, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f,
+	0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x67, 0x65, 0x4f, 0x72, 0x69, 0x65, 0x6e, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x10, 0x70, 0x61, 0x67, 0x65, 0x4f, 0x72, 0x69, 0x65, 0x6e,
+	0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x66, 0x69, 0x73, 0x63, 0x61,
+	0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x24, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x66, 0x69, 0x73, 0x63, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x32, 0x0a, 0x08,
+	0x62, 0x61, 0x72, 0x63, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x25, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16,
+	0x2e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42,
+	0x61, 0x72, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x08, 0x62, 0x61, 0x72, 0x63, 0x6f, 0x64, 0x65, 0x73,
+	0x12, 0x2e, 0x0a, 0x13, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x65, 0x64, 0x5f, 0x69, 0x74, 0x65,
+	0x6d, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x26, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x66,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x65, 0x64, 0x49, 0x74, 0x65, 0x6d, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x1b, 0x0a, 0x09, 0x76, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x27, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x76, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x1f, 0x0a,
+	0x0b, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x28, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x0a, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x2c,
+	0x0a, 0x12, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x6d,
+	0x69, 0x6e, 0x6f, 0x72, 0x18, 0x29, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x4d, 0x69, 0x6e, 0x6f, 0x72, 0x12, 0x25, 0x0a, 0x0e,
+	0x73, 0x75, 0x62, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x6d, 0x69, 0x6e, 0x6f, 0x72, 0x18, 0x2a,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x73, 0x75, 0x62, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x4d, 0x69,
+	0x6e, 0x6f, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x74, 0x61, 0x78, 0x5f, 0x6d, 0x69, 0x6e, 0x6f, 0x72,
+	0x18, 0x2b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x74, 0x61, 0x78, 0x4d, 0x69, 0x6e, 0x6f, 0x72,
+	0x12, 0x1b, 0x0a, 0x09, 0x74, 0x69, 0x70, 0x5f, 0x6d, 0x69, 0x6e, 0x6f, 0x72, 0x18, 0x2c, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x08, 0x74, 0x69, 0x70, 0x4d, 0x69, 0x6e, 0x6f, 0x72, 0x12, 0x31, 0x0a,
+	0x15, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x73, 0x75, 0x6d,
+	0x5f, 0x6d, 0x69, 0x6e, 0x6f, 0x72, 0x18, 0x2d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x12, 0x69, 0x74,
+	0x65, 0x6d, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x53, 0x75, 0x6d, 0x4d, 0x69, 0x6e, 0x6f, 0x72,
+	0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x6f, 0x79, 0x61, 0x6c, 0x74, 0x79, 0x5f, 0x63, 0x61, 0x72, 0x64,
+	0x18, 0x2e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6c, 0x6f, 0x79, 0x61, 0x6c, 0x74, 0x79, 0x43,
+	0x61, 0x72, 0x64, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x73, 0x5f, 0x72,
+	0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69, 0x6c, 0x65, 0x22, 0xdf, 0x05, 0x0a, 0x0b, 0x52, 0x65, 0x63,
+	0x65, 0x69, 0x70, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64,
+	0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x71, 0x75,
+	0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x71, 0x75,
+	0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x72,
+	0x69, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65,
+	0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x50, 0x72, 0x69, 0x63,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x64, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x08, 0x63, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x64, 0x12, 0x1d, 0x0a,
+	0x0a, 0x75, 0x6e, 0x69, 0x74, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x75, 0x6e, 0x69, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c,
+	0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x43, 0x6f, 0x64, 0x65, 0x12,
+	0x3b, 0x0a, 0x05, 0x65, 0x78, 0x74, 0x72, 0x61, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x25,
+	0x2e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52,
+	0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x2e, 0x45, 0x78, 0x74, 0x72, 0x61,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x65, 0x78, 0x74, 0x72, 0x61, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e,
+	0x63, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64,
+	0x65, 0x6e, 0x63, 0x65, 0x12, 0x63, 0x0a, 0x13, 0x70, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x79,
+	0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x0c, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x32, 0x2e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x2e, 0x50, 0x72,
+	0x6f, 0x70, 0x65, 0x72, 0x74, 0x79, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x12, 0x70, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x79, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x61, 0x77,
+	0x5f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0e, 0x72, 0x61, 0x77, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x08, 0x74, 0x61, 0x78, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x0f,
+	0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x07, 0x74, 0x61, 0x78, 0x52, 0x61, 0x74, 0x65, 0x88,
+	0x01, 0x01, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x10, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x67, 0x65,
+	0x18, 0x11, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x70, 0x61, 0x67, 0x65, 0x1a, 0x38, 0x0a, 0x0a,
+	0x45, 0x78, 0x74, 0x72, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x45, 0x0a, 0x17, 0x50, 0x72, 0x6f, 0x70, 0x65, 0x72,
+	0x74, 0x79, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x02, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x0b, 0x0a,
+	0x09, 0x5f, 0x74, 0x61, 0x78, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x22, 0x46, 0x0a, 0x10, 0x44, 0x65,
+	0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x4c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e,
+	0x63, 0x65, 0x22, 0x6e, 0x0a, 0x07, 0x42, 0x61, 0x72, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x70, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x70, 0x61, 0x67,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x22, 0x63, 0x0a, 0x0f, 0x50, 0x61, 0x67, 0x65, 0x4f, 0x72, 0x69, 0x65, 0x6e, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x04, 0x70, 0x61, 0x67, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x6f, 0x72, 0x69,
+	0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x6f, 0x72, 0x69, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x72,
+	0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x72,
+	0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x58, 0x0a, 0x0c, 0x52, 0x65, 0x63, 0x65, 0x69,
+	0x70, 0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52,
+	0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x22, 0x8d, 0x01, 0x0a, 0x09, 0x54, 0x65, 0x78, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12,
+	0x12, 0x0a, 0x04, 0x70, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x70,
+	0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x63, 0x6f, 0x6e,
+	0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x38, 0x0a, 0x0c, 0x62, 0x6f, 0x75, 0x6e, 0x64,
+	0x69, 0x6e, 0x67, 0x5f, 0x62, 0x6f, 0x78, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65,
+	0x72, 0x74, 0x65, 0x78, 0x52, 0x0b, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x42, 0x6f,
+	0x78, 0x22, 0x24, 0x0a, 0x06, 0x56, 0x65, 0x72, 0x74, 0x65, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x78,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x02, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x02, 0x52, 0x01, 0x79, 0x22, 0x58, 0x0a, 0x0e, 0x57, 0x6f, 0x72, 0x64, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x67,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x70, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78,
+	0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63,
+	0x65, 0x22, 0xcb, 0x01, 0x0a, 0x15, 0x57, 0x6f, 0x72, 0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64,
+	0x65, 0x6e, 0x63, 0x65, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x77,
+	0x6f, 0x72, 0x64, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x09, 0x77, 0x6f, 0x72, 0x64, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x6d, 0x65,
+	0x61, 0x6e, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x0e, 0x6d, 0x65, 0x61, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65,
+	0x6e, 0x63, 0x65, 0x12, 0x30, 0x0a, 0x14, 0x6c, 0x6f, 0x77, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x64, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x12, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65,
+	0x57, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x38, 0x0a, 0x18, 0x6c, 0x6f, 0x77, 0x5f, 0x63, 0x6f, 0x6e,
+	0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c,
+	0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x52, 0x16, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x42,
+	0x34, 0x5a, 0x32, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x61,
+	0x6b, 0x75, 0x62, 0x73, 0x6f, 0x61, 0x64, 0x2f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x2d,
+	0x6f, 0x63, 0x72, 0x2d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x72, 0x65, 0x63, 0x65,
+	0x69, 0x70, 0x74, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_receiptpb_receipt_proto_rawDescOnce sync.Once
+	file_receiptpb_receipt_proto_rawDescData = file_receiptpb_receipt_proto_rawDesc
+)
+
+func file_receiptpb_receipt_proto_rawDescGZIP() []byte {
+	file_receiptpb_receipt_proto_rawDescOnce.Do(func() {
+		file_receiptpb_receipt_proto_rawDescData = protoimpl.X.CompressGZIP(file_receiptpb_receipt_proto_rawDescData)
+	})
+	return file_receiptpb_receipt_proto_rawDescData
+}
+
+var file_receiptpb_receipt_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_receiptpb_receipt_proto_goTypes = []interface{}{
+	(*OCRResponse)(nil),           // 0: receiptocr.v1.OCRResponse
+	(*Receipt)(nil),               // 1: receiptocr.v1.Receipt
+	(*ReceiptItem)(nil),           // 2: receiptocr.v1.ReceiptItem
+	(*DetectedLanguage)(nil),      // 3: receiptocr.v1.DetectedLanguage
+	(*Barcode)(nil),               // 4: receiptocr.v1.Barcode
+	(*PageOrientation)(nil),       // 5: receiptocr.v1.PageOrientation
+	(*ReceiptField)(nil),          // 6: receiptocr.v1.ReceiptField
+	(*TextBlock)(nil),             // 7: receiptocr.v1.TextBlock
+	(*Vertex)(nil),                // 8: receiptocr.v1.Vertex
+	(*WordConfidence)(nil),        // 9: receiptocr.v1.WordConfidence
+	(*WordConfidenceSummary)(nil), // 10: receiptocr.v1.WordConfidenceSummary
+	nil,                           // 11: receiptocr.v1.ReceiptItem.ExtraEntry
+	nil,                           // 12: receiptocr.v1.ReceiptItem.PropertyConfidenceEntry
+}
+var file_receiptpb_receipt_proto_depIdxs = []int32{
+	1,  // 0: receiptocr.v1.OCRResponse.receipt:type_name -> receiptocr.v1.Receipt
+	7,  // 1: receiptocr.v1.OCRResponse.blocks:type_name -> receiptocr.v1.TextBlock
+	9,  // 2: receiptocr.v1.OCRResponse.words:type_name -> receiptocr.v1.WordConfidence
+	10, // 3: receiptocr.v1.OCRResponse.word_confidence:type_name -> receiptocr.v1.WordConfidenceSummary
+	3,  // 4: receiptocr.v1.Receipt.detected_languages:type_name -> receiptocr.v1.DetectedLanguage
+	2,  // 5: receiptocr.v1.Receipt.items:type_name -> receiptocr.v1.ReceiptItem
+	6,  // 6: receiptocr.v1.Receipt.fields:type_name -> receiptocr.v1.ReceiptField
+	5,  // 7: receiptocr.v1.Receipt.page_orientations:type_name -> receiptocr.v1.PageOrientation
+	4,  // 8: receiptocr.v1.Receipt.barcodes:type_name -> receiptocr.v1.Barcode
+	11, // 9: receiptocr.v1.ReceiptItem.extra:type_name -> receiptocr.v1.ReceiptItem.ExtraEntry
+	12, // 10: receiptocr.v1.ReceiptItem.property_confidence:type_name -> receiptocr.v1.ReceiptItem.PropertyConfidenceEntry
+	8,  // 11: receiptocr.v1.TextBlock.bounding_box:type_name -> receiptocr.v1.Vertex
+	12, // [12:12] is the sub-list for method output_type
+	12, // [12:12] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_receiptpb_receipt_proto_init() }
+func file_receiptpb_receipt_proto_init() {
+	if File_receiptpb_receipt_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_receiptpb_receipt_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OCRResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Receipt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReceiptItem); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DetectedLanguage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Barcode); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PageOrientation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReceiptField); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TextBlock); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Vertex); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WordConfidence); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_receiptpb_receipt_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WordConfidenceSummary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_receiptpb_receipt_proto_msgTypes[1].OneofWrappers = []interface{}{}
+	file_receiptpb_receipt_proto_msgTypes[2].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_receiptpb_receipt_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_receiptpb_receipt_proto_goTypes,
+		DependencyIndexes: file_receiptpb_receipt_proto_depIdxs,
+		MessageInfos:      file_receiptpb_receipt_proto_msgTypes,
+	}.Build()
+	File_receiptpb_receipt_proto = out.File
+	file_receiptpb_receipt_proto_rawDesc = nil
+	file_receiptpb_receipt_proto_goTypes = nil
+	file_receiptpb_receipt_proto_depIdxs = nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+// Schema sources reported by /api/schema.
+const (
+	schemaSourceProcessor = "processor"
+	schemaSourceObserved  = "observed"
+)
+
+// SchemaResponse lists the entity types a processor emits.
+type SchemaResponse struct {
+	Success     bool         `json:"success"`
+	Error       string       `json:"error,omitempty"`
+	ProcessorID string       `json:"processor_id"`
+	Source      string       `json:"source"`
+	EntityTypes []EntityType `json:"entity_types"`
+}
+
+// EntityType is an entity type such as "line_item" and the property types
+// nested under it. ValueType and Occurrence are only known from the
+// processor's schema.
+type EntityType struct {
+	Name       string       `json:"name"`
+	ValueType  string       `json:"value_type,omitempty"`
+	Occurrence string       `json:"occurrence,omitempty"`
+	Properties []EntityType `json:"properties,omitempty"`
+}
+
+// observedEntityTypes remembers the entity and property types seen in
+// responses, per processor, for processors whose schema can't be read.
+type observedEntityTypes struct {
+	mu    sync.Mutex
+	types map[string]map[string]map[string]bool
+}
+
+var observedTypes = &observedEntityTypes{types: make(map[string]map[string]map[string]bool)}
+
+func (o *observedEntityTypes) Record(processorID string, entities []*documentaipb.Document_Entity) {
+	if len(entities) == 0 {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	types := o.types[processorID]
+	if types == nil {
+		types = make(map[string]map[string]bool)
+		o.types[processorID] = types
+	}
+	for _, entity := range entities {
+		if entity.Type == "" {
+			continue
+		}
+		properties := types[entity.Type]
+		if properties == nil {
+			properties = make(map[string]bool)
+			types[entity.Type] = properties
+		}
+		for _, property := range entity.Properties {
+			if property.Type != "" {
+				properties[property.Type] = true
+			}
+		}
+	}
+}
+
+// Get returns the types seen for processorID, sorted by name.
+func (o *observedEntityTypes) Get(processorID string) []EntityType {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	types := make([]EntityType, 0, len(o.types[processorID]))
+	for name, properties := range o.types[processorID] {
+		entityType := EntityType{Name: name}
+		for property := range properties {
+			entityType.Properties = append(entityType.Properties, EntityType{Name: property})
+		}
+		sort.Slice(entityType.Properties, func(i, j int) bool {
+			return entityType.Properties[i].Name < entityType.Properties[j].Name
+		})
+		types = append(types, entityType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+// handleSchema serves GET /api/schema, which lists the entity types of the
+// processor a request would be routed to (?instructions= picks the route as
+// for /api/ocr). The schema is read from the processor's default version;
+// pretrained processors often don't publish one, in which case the types
+// observed in this process's responses so far are returned instead.
+func handleSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	instructions := r.URL.Query().Get("instructions")
+	if instructions == "" {
+		instructions = os.Getenv("DEFAULT_INSTRUCTIONS")
+	}
+	processorID := selectProcessorID(instructions)
+
+	response := SchemaResponse{Success: true, ProcessorID: processorID, Source: schemaSourceProcessor}
+	types, err := processorEntityTypes(r.Context(), processorID)
+	if err != nil {
+		logWarnf("Failed to read schema of processor %s: %v", processorID, err)
+	}
+	if len(types) == 0 {
+		response.Source = schemaSourceObserved
+		types = observedTypes.Get(processorID)
+	}
+	response.EntityTypes = types
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := writeJSON(w, r, response); err != nil {
+		logErrorf("Failed to write response: %v", err)
+	}
+}
+
+// processorEntityTypes reads the document schema of processorID's default
+// version.
+func processorEntityTypes(ctx context.Context, processorID string) ([]EntityType, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client, err := newDocumentAIClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Document AI client: %v", err)
+	}
+	defer client.Close()
+
+	name := fmt.Sprintf("projects/%s/locations/%s/processors/%s",
+		os.Getenv("GOOGLE_CLOUD_PROJECT"), os.Getenv("DOCUMENT_AI_LOCATION"), processorID)
+	processor, err := client.GetProcessor(ctx, &documentaipb.GetProcessorRequest{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processor: %v", err)
+	}
+	if processor.DefaultProcessorVersion == "" {
+		return nil, nil
+	}
+	version, err := client.GetProcessorVersion(ctx, &documentaipb.GetProcessorVersionRequest{Name: processor.DefaultProcessorVersion})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processor version: %v", err)
+	}
+	if version.DocumentSchema == nil {
+		return nil, nil
+	}
+
+	var types []EntityType
+	for _, schemaType := range version.DocumentSchema.EntityTypes {
+		entityType := EntityType{Name: schemaType.Name}
+		for _, property := range schemaType.Properties {
+			propertyType := EntityType{Name: property.Name, ValueType: property.ValueType}
+			if property.OccurrenceType != documentaipb.DocumentSchema_EntityType_Property_OCCURRENCE_TYPE_UNSPECIFIED {
+				propertyType.Occurrence = strings.ToLower(property.OccurrenceType.String())
+			}
+			entityType.Properties = append(entityType.Properties, propertyType)
+		}
+		types = append(types, entityType)
+	}
+	return types, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+)
+
+type selfTestReport struct {
+	OK          bool            `json:"ok"`
+	Credentials readinessCheck  `json:"credentials"`
+	Config      map[string]bool `json:"config"`
+	Processor   selfTestCheck   `json:"processor"`
+}
+
+type selfTestCheck struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// handleSelfTest runs the startup connectivity check on demand. Failures are
+// reported in the response; the process keeps running.
+func handleSelfTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := selfTestReport{
+		Credentials: checkCredentialsFile(),
+		Config:      make(map[string]bool),
+	}
+	for _, envVar := range []string{"GOOGLE_CLOUD_PROJECT", "DOCUMENT_AI_LOCATION", "DOCUMENT_AI_PROCESSOR_ID"} {
+		report.Config[envVar] = os.Getenv(envVar) != ""
+	}
+
+	start := time.Now()
+	err := testGoogleCloudConnection()
+	report.Processor.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		logWarnf("Self-test failed: %v", err)
+		report.Processor.Error = err.Error()
+	} else {
+		report.Processor.OK = true
+	}
+
+	report.OK = report.Credentials.OK && report.Processor.OK
+	for _, set := range report.Config {
+		report.OK = report.OK && set
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !report.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
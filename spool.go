@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// maxDocumentBytes is the largest document accepted for OCR, from
+// MAX_DOCUMENT_BYTES (default 20 MiB, Document AI's limit for inline
+// documents).
+func maxDocumentBytes() int64 {
+	return int64(intFromEnv("MAX_DOCUMENT_BYTES", 20<<20))
+}
+
+// maxRequestBytes caps /api/ocr request bodies: room for MAX_STITCH_IMAGES
+// base64-encoded documents of maxDocumentBytes, plus 1 MiB for the other
+// fields.
+func maxRequestBytes() int64 {
+	images := int64(intFromEnv("MAX_STITCH_IMAGES", 5))
+	return images*int64(base64.StdEncoding.EncodedLen(int(maxDocumentBytes()))) + 1<<20
+}
+
+// readBody reads r fully, failing with errDocumentTooLarge as soon as it
+// passes maxBytes. Bodies larger than SPOOL_THRESHOLD_BYTES (default 8 MiB)
+// are spooled to a temporary file while downloading and then read back in
+// one allocation of the exact size, instead of growing an in-memory buffer
+// that can briefly need several times the document size.
+//
+// The result still has to be a single buffer: Document AI takes the document
+// inline in the request, and JSON bodies and signatures are decoded and
+// verified as a whole. The read back goes through the same maxBytes cap, so
+// the allocation is never larger than the limit the caller asked for.
+func readBody(r io.Reader, maxBytes int64) ([]byte, error) {
+	threshold := int64(intFromEnv("SPOOL_THRESHOLD_BYTES", 8<<20))
+	r = io.LimitReader(r, maxBytes+1)
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, threshold+1)
+	if err == io.EOF || (err == nil && n <= threshold) {
+		if n > maxBytes {
+			return nil, bodyTooLarge(maxBytes)
+		}
+		return buf.Bytes(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := ioutil.TempFile("", "ocr-upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err := buf.WriteTo(file); err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %v", err)
+	}
+	// Release the in-memory prefix before copying the rest
+	buf = bytes.Buffer{}
+
+	size, err := io.Copy(file, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %v", err)
+	}
+	size += threshold + 1
+	if size > maxBytes {
+		return nil, bodyTooLarge(maxBytes)
+	}
+	logDebugf("Spooled %d byte body to %s", size, file.Name())
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read temp file: %v", err)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(io.LimitReader(file, maxBytes), data); err != nil {
+		return nil, fmt.Errorf("failed to read temp file: %v", err)
+	}
+	return data, nil
+}
+
+func bodyTooLarge(maxBytes int64) error {
+	return fmt.Errorf("%w: more than %d bytes", errDocumentTooLarge, maxBytes)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadBody(t *testing.T) {
+	t.Setenv("SPOOL_THRESHOLD_BYTES", "16")
+	tests := []struct {
+		name     string
+		size     int
+		maxBytes int64
+		wantErr  error
+	}{
+		{name: "in memory", size: 10, maxBytes: 100},
+		{name: "spooled", size: 64, maxBytes: 100},
+		{name: "exactly the limit", size: 100, maxBytes: 100},
+		{name: "over the limit in memory", size: 12, maxBytes: 10, wantErr: errDocumentTooLarge},
+		{name: "over the limit while spooling", size: 1000, maxBytes: 100, wantErr: errDocumentTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := bytes.Repeat([]byte("x"), tt.size)
+			got, err := readBody(bytes.NewReader(content), tt.maxBytes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readBody error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !bytes.Equal(got, content) {
+				t.Errorf("readBody returned %d bytes, want %d", len(got), len(content))
+			}
+		})
+	}
+}
+
+func TestHandleOCRSizeLimits(t *testing.T) {
+	installFakeProcessor(t, &fakeDocumentProcessor{document: testReceiptDocument()})
+	t.Setenv("SPOOL_THRESHOLD_BYTES", "64")
+	t.Setenv("MAX_DOCUMENT_BYTES", "1024")
+	t.Setenv("MAX_STITCH_IMAGES", "1")
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"spooled body within limits", ocrRequestBody(t, testPNG(t, 8)), http.StatusOK},
+		{"document over the limit", ocrRequestBody(t, bytes.Repeat([]byte("x"), 1100)), http.StatusRequestEntityTooLarge},
+		{"body over the limit", `{"instructions":"` + strings.Repeat("x", 3<<20) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := postOCR(t, tt.body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+	"golang.org/x/text/language"
+)
+
+// stitchWindow is the most lines at the top and bottom of a part taken as
+// its header or footer.
+const stitchWindow = 8
+
+// ImageSource is one photo of a receipt split across several images. Like
+// a single-image request, exactly one field must be set.
+type ImageSource struct {
+	ImageURL    string `json:"image_url,omitempty"`
+	Base64Image string `json:"base64_image,omitempty"`
+	DataURI     string `json:"data_uri,omitempty"`
+}
+
+func validateImageSources(images []ImageSource) error {
+	if maxImages := intFromEnv("MAX_STITCH_IMAGES", 5); len(images) > maxImages {
+		return fmt.Errorf("at most %d images can be stitched", maxImages)
+	}
+	for i, image := range images {
+		part := OCRRequest{ImageURL: image.ImageURL, Base64Image: image.Base64Image, DataURI: image.DataURI}
+		if err := validateImageSource(part); err != nil {
+			return fmt.Errorf("images[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// processStitched runs OCR on each image of a split receipt in order, joins
+// the texts with stitchTexts and parses the combined text. Entities can't be
+// combined across images, so the receipt comes from the text-based parsing
+// alone, as for /api/parse.
+func processStitched(ctx context.Context, req OCRRequest) (*ocrResult, error) {
+	texts := make([]string, 0, len(req.Images))
+	partial := false
+	for i, image := range req.Images {
+		part := req
+		part.Images = nil
+		part.ImageURL, part.Base64Image, part.DataURI = image.ImageURL, image.Base64Image, image.DataURI
+		part.Mode = modeText
+		part.IncludeBlocks, part.IncludeWordConfidence = false, false
+		part.IncludeFullText = nil
+
+		result, err := processStitchedPart(ctx, part)
+		if errors.Is(err, errSoftDeadline) && len(texts) > 0 {
+			logWarnf("Soft deadline passed after %d of %d images, returning partial results", len(texts), len(req.Images))
+			partial = true
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i+1, err)
+		}
+		texts = append(texts, strings.Join(result.Texts, "\n"))
+	}
+
+	text := stitchTexts(texts)
+	result := &ocrResult{NoTextDetected: text == "", Partial: partial}
+	if req.wantsFullText() && text != "" {
+		result.Texts = []string{text}
+	}
+	if req.Mode == modeText {
+		return result, nil
+	}
+
+	_, receipt := extractDataFromDocument(&documentaipb.Document{Text: text}, req.Instructions, req.ExpectedCurrency)
+	if req.Locale != "" && receipt.TotalAmountValue != 0 {
+		receipt.FormattedTotal = formatAmount(receipt.TotalAmountValue, receipt.Currency, language.Make(req.Locale))
+	}
+	textHash := sha256.Sum256([]byte(text))
+	assignItemIDs(receipt.Items, hex.EncodeToString(textHash[:]))
+	sortItems(receipt.Items, req.SortItems)
+	result.Receipt = receipt
+	return result, nil
+}
+
+// processStitchedPart OCRs one image of a split receipt, failing with
+// errSoftDeadline when the soft deadline, if any, passes first.
+func processStitchedPart(ctx context.Context, part OCRRequest) (*ocrResult, error) {
+	deadline, ok := softDeadlineFrom(ctx)
+	if !ok {
+		return processDocument(ctx, part)
+	}
+	partCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	result, err := processDocument(partCtx, part)
+	if err != nil && partCtx.Err() != nil && ctx.Err() == nil {
+		return nil, errSoftDeadline
+	}
+	return result, err
+}
+
+// stitchTexts joins the texts of consecutive photos of one receipt. At each
+// join it drops header lines the next part repeats from the first part,
+// footer lines the previous part shares with the last part, and the lines
+// both photos captured around the split, found as the longest run of lines
+// ending the previous part that also starts the next one. Headers and
+// footers are the lines without amounts at the top and bottom of a part.
+func stitchTexts(texts []string) string {
+	if len(texts) == 0 {
+		return ""
+	}
+	parts := make([][]string, len(texts))
+	for i, text := range texts {
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				parts[i] = append(parts[i], line)
+			}
+		}
+	}
+
+	header := lineSet(headerLines(parts[0]))
+	footer := lineSet(footerLines(parts[len(parts)-1]))
+
+	stitched := append([]string(nil), parts[0]...)
+	for _, next := range parts[1:] {
+		for len(next) > 0 && header[stitchKey(next[0])] {
+			next = next[1:]
+		}
+		for len(stitched) > 0 && footer[stitchKey(stitched[len(stitched)-1])] {
+			stitched = stitched[:len(stitched)-1]
+		}
+		overlap := joinOverlap(stitched, next)
+		stitched = append(stitched, next[overlap:]...)
+	}
+	return strings.Join(stitched, "\n")
+}
+
+// joinOverlap returns the length of the longest run of lines that ends
+// previous and starts next. Runs made only of separators such as "-----"
+// don't count, since they repeat throughout a receipt.
+func joinOverlap(previous, next []string) int {
+	for n := min(len(previous), len(next)); n > 0; n-- {
+		meaningful, equal := false, true
+		for i := 0; i < n && equal; i++ {
+			key := stitchKey(previous[len(previous)-n+i])
+			equal = key == stitchKey(next[i])
+			meaningful = meaningful || strings.ContainsFunc(key, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+		}
+		if equal && meaningful {
+			return n
+		}
+	}
+	return 0
+}
+
+// headerLines returns the lines before the first one with an amount, which
+// hold the merchant header.
+func headerLines(lines []string) []string {
+	end := 0
+	for end < len(lines) && end < stitchWindow && len(findAmounts(lines[end])) == 0 {
+		end++
+	}
+	return lines[:end]
+}
+
+// footerLines returns the lines after the last one with an amount.
+func footerLines(lines []string) []string {
+	start := len(lines)
+	for start > 0 && len(lines)-start < stitchWindow && len(findAmounts(lines[start-1])) == 0 {
+		start--
+	}
+	return lines[start:]
+}
+
+func lineSet(lines []string) map[string]bool {
+	set := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		set[stitchKey(line)] = true
+	}
+	return set
+}
+
+// stitchKey compares lines ignoring case and spacing, which OCR reads
+// differently from one photo to the next.
+func stitchKey(line string) string {
+	return strings.Join(strings.Fields(strings.ToLower(line)), " ")
+}
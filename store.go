@@ -0,0 +1,128 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var errResultNotFound = errors.New("result not found")
+
+// StoredResult is what the service persists for a job: its status and,
+// once finished, either the result or the error response it produced. It is
+// plain data so backends can serialize it as JSON.
+type StoredResult struct {
+	Status string        `json:"status"`
+	Result *ocrResult    `json:"result,omitempty"`
+	Error  *errorOutcome `json:"error,omitempty"`
+}
+
+// ResultStore persists job results. Implementations must be safe for
+// concurrent use and must drop results once their TTL has passed (Get then
+// returns errResultNotFound). Only the in-memory store ships with the
+// service; a Redis or SQL backend would be added to newResultStore.
+type ResultStore interface {
+	Save(ctx context.Context, id string, result StoredResult, ttl time.Duration) error
+	Get(ctx context.Context, id string) (StoredResult, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// memoryResultStore keeps results in process memory, so they are lost on
+// restart and not shared between instances. It holds at most capacity
+// results and evicts the least recently used first. Expired results are
+// dropped when they are looked up or reach the back of the list.
+type memoryResultStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	results  map[string]*list.Element
+}
+
+type memoryResult struct {
+	id      string
+	result  StoredResult
+	expires time.Time
+}
+
+func newMemoryResultStore(capacity int) *memoryResultStore {
+	return &memoryResultStore{
+		capacity: capacity,
+		order:    list.New(),
+		results:  make(map[string]*list.Element),
+	}
+}
+
+func (s *memoryResultStore) Save(ctx context.Context, id string, result StoredResult, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	stored := &memoryResult{id: id, result: result, expires: now.Add(ttl)}
+	if element, ok := s.results[id]; ok {
+		element.Value = stored
+		s.order.MoveToFront(element)
+	} else {
+		s.results[id] = s.order.PushFront(stored)
+	}
+	for s.order.Len() > 0 {
+		oldest := s.order.Back()
+		if s.order.Len() <= s.capacity && !now.After(oldest.Value.(*memoryResult).expires) {
+			break
+		}
+		s.remove(oldest)
+	}
+	return nil
+}
+
+func (s *memoryResultStore) Get(ctx context.Context, id string) (StoredResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.results[id]
+	if !ok {
+		return StoredResult{}, errResultNotFound
+	}
+	stored := element.Value.(*memoryResult)
+	if time.Now().After(stored.expires) {
+		s.remove(element)
+		return StoredResult{}, errResultNotFound
+	}
+	s.order.MoveToFront(element)
+	return stored.result, nil
+}
+
+func (s *memoryResultStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.results[id]; ok {
+		s.remove(element)
+	}
+	return nil
+}
+
+func (s *memoryResultStore) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.results, element.Value.(*memoryResult).id)
+}
+
+// newResultStore returns the backend named by RESULT_STORE (default
+// "memory").
+func newResultStore() (ResultStore, error) {
+	switch backend := os.Getenv("RESULT_STORE"); backend {
+	case "", "memory":
+		return newMemoryResultStore(intFromEnv("RESULT_STORE_CAPACITY", 10000)), nil
+	default:
+		return nil, fmt.Errorf("unsupported RESULT_STORE %q: must be memory", backend)
+	}
+}
+
+// persistResults reports whether synchronous /api/ocr results are saved too,
+// so they can be fetched again by job ID.
+func persistResults() bool {
+	return os.Getenv("PERSIST_RESULTS") == "true"
+}
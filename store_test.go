@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testResultStore checks the ResultStore contract, so every backend can run
+// it.
+func testResultStore(t *testing.T, newStore func() ResultStore) {
+	ctx := context.Background()
+	completed := StoredResult{Status: jobCompleted, Result: &ocrResult{Texts: []string{"MLEKO 3,99"}}}
+
+	t.Run("save and get", func(t *testing.T) {
+		store := newStore()
+		if err := store.Save(ctx, "job", completed, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		got, err := store.Get(ctx, "job")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != jobCompleted || got.Result == nil || got.Result.Texts[0] != "MLEKO 3,99" {
+			t.Errorf("Get = %+v, want the saved result", got)
+		}
+	})
+
+	t.Run("save replaces", func(t *testing.T) {
+		store := newStore()
+		store.Save(ctx, "job", StoredResult{Status: jobProcessing}, time.Minute)
+		store.Save(ctx, "job", completed, time.Minute)
+		if got, err := store.Get(ctx, "job"); err != nil || got.Status != jobCompleted {
+			t.Errorf("Get = %+v, %v, want the latest result", got, err)
+		}
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		if _, err := newStore().Get(ctx, "missing"); !errors.Is(err, errResultNotFound) {
+			t.Errorf("Get error = %v, want errResultNotFound", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		store := newStore()
+		store.Save(ctx, "job", completed, time.Minute)
+		if err := store.Delete(ctx, "job"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Get(ctx, "job"); !errors.Is(err, errResultNotFound) {
+			t.Errorf("Get after Delete error = %v, want errResultNotFound", err)
+		}
+		if err := store.Delete(ctx, "job"); err != nil {
+			t.Errorf("deleting a missing result failed: %v", err)
+		}
+	})
+
+	t.Run("ttl expiry", func(t *testing.T) {
+		store := newStore()
+		store.Save(ctx, "short", completed, 10*time.Millisecond)
+		store.Save(ctx, "long", completed, time.Minute)
+		time.Sleep(20 * time.Millisecond)
+		if _, err := store.Get(ctx, "short"); !errors.Is(err, errResultNotFound) {
+			t.Errorf("Get of expired result error = %v, want errResultNotFound", err)
+		}
+		if _, err := store.Get(ctx, "long"); err != nil {
+			t.Errorf("Get of live result failed: %v", err)
+		}
+	})
+}
+
+func TestMemoryResultStore(t *testing.T) {
+	testResultStore(t, func() ResultStore { return newMemoryResultStore(100) })
+}
+
+func TestMemoryResultStoreEviction(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryResultStore(2)
+	store.Save(ctx, "a", StoredResult{Status: jobCompleted}, time.Minute)
+	store.Save(ctx, "b", StoredResult{Status: jobCompleted}, time.Minute)
+	store.Get(ctx, "a")
+	store.Save(ctx, "c", StoredResult{Status: jobCompleted}, time.Minute)
+
+	if _, err := store.Get(ctx, "b"); !errors.Is(err, errResultNotFound) {
+		t.Errorf("least recently used result was kept")
+	}
+	for _, id := range []string{"a", "c"} {
+		if _, err := store.Get(ctx, id); err != nil {
+			t.Errorf("Get(%q) failed: %v", id, err)
+		}
+	}
+}
+
+func TestMemoryResultStoreDropsExpiredOnSave(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryResultStore(100)
+	store.Save(ctx, "old", StoredResult{Status: jobCompleted}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	store.Save(ctx, "new", StoredResult{Status: jobCompleted}, time.Minute)
+
+	if n := len(store.results); n != 1 {
+		t.Errorf("store holds %d results, want only the live one", n)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// transactionNumberRegex matches a labelled receipt or transaction number
+// ("Nr paragonu: 1234", "Paragon fiskalny nr 567", "Receipt No. A-102",
+// "Transaction #88231"). The value must contain a digit so labels followed
+// by ordinary words are ignored.
+var transactionNumberRegex = regexp.MustCompile(`(?i)(?:nr\s+paragonu|paragon(?:\s+fiskalny)?\s+nr|nr\s+transakcji|transakcja\s+nr|receipt\s+(?:no\.?|number|#)|transaction\s*(?:no\.?|number|id|#)?)\s*[:#]?\s*([A-Z0-9][A-Z0-9/-]*\d[A-Z0-9/-]*)`)
+
+// cashierRegex matches a cashier label and its value up to the end of the
+// line ("Kasjer: Anna K.", "Kasjer nr 3", "Cashier: 017").
+var cashierRegex = regexp.MustCompile(`(?i)(?:^|[^\pL])(?:kasjer(?:ka)?|cashier)\s*(?:nr\.?\s*|#\s*|id\s*)?[:#]?\s*([\pL\d][\pL\d .'-]{0,29})\s*$`)
+
+// extractTransactionNumber returns the first labelled receipt or
+// transaction number in text, or an empty string.
+func extractTransactionNumber(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if match := transactionNumberRegex.FindStringSubmatch(line); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// extractCashier returns the cashier name or ID printed after a "Kasjer" or
+// "Cashier" label, or an empty string.
+func extractCashier(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if match := cashierRegex.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			return strings.TrimSpace(match[1])
+		}
+	}
+	return ""
+}
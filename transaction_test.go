@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestExtractTransactionNumber(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Nr paragonu: 1234", "1234"},
+		{"PARAGON FISKALNY nr 567", "567"},
+		{"Receipt No. A-102", "A-102"},
+		{"Transaction #88231", "88231"},
+		{"Nr transakcji 2024/05/17", "2024/05/17"},
+		{"BIEDRONKA\nMLEKO 3,99\nTransaction ID: TX9", "TX9"},
+		{"Transaction approved", ""},
+		{"PARAGON FISKALNY", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := extractTransactionNumber(tt.text); got != tt.want {
+				t.Errorf("extractTransactionNumber(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractCashier(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Kasjer: Anna K.", "Anna K."},
+		{"Kasjer nr 3", "3"},
+		{"Kasjerka Maria", "Maria"},
+		{"Cashier: 017", "017"},
+		{"  CASHIER #12  ", "12"},
+		{"SUMA PLN 3,99\nKasjer: Jan", "Jan"},
+		{"Kasjer:", ""},
+		{"MLEKO 3,99", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := extractCashier(tt.text); got != tt.want {
+				t.Errorf("extractCashier(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
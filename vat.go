@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultVATRates are the Polish PTU letters printed after each item's
+// price, in percent.
+var defaultVATRates = map[string]float64{"A": 23, "B": 8, "C": 5, "D": 0}
+
+// vatRates maps VAT letters to rates. Letters not in it, such as "E" for
+// exempt goods, are left on the line.
+var vatRates = defaultVATRates
+
+// vatMarkerRegex matches a single VAT letter directly after a price at the
+// end of a line ("Mleko 4,99 A", "Chleb 1 x4,99 4,99B").
+var vatMarkerRegex = regexp.MustCompile(`\d[.,]\d{2}-?\s*([A-Z])$`)
+
+// loadVATRates parses VAT_RATES, a JSON object such as {"A": 23, "B": 8}
+// that replaces the default table.
+func loadVATRates() error {
+	raw := os.Getenv("VAT_RATES")
+	if raw == "" {
+		return nil
+	}
+
+	var rates map[string]float64
+	if err := json.Unmarshal([]byte(raw), &rates); err != nil {
+		return fmt.Errorf("invalid VAT_RATES: %v", err)
+	}
+	normalized := make(map[string]float64, len(rates))
+	for letter, rate := range rates {
+		letter = strings.ToUpper(strings.TrimSpace(letter))
+		if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'Z' {
+			return fmt.Errorf("invalid VAT_RATES: %q is not a single letter", letter)
+		}
+		if rate < 0 || rate >= 100 {
+			return fmt.Errorf("invalid VAT_RATES: rate %v for %s must be a percentage", rate, letter)
+		}
+		normalized[letter] = rate
+	}
+
+	vatRates = normalized
+	return nil
+}
+
+// splitVATMarker removes a trailing VAT letter from line and returns the
+// line without it and the letter's rate. Lines without a known letter are
+// returned unchanged with a nil rate.
+func splitVATMarker(line string) (string, *float64) {
+	trimmed := strings.TrimSpace(line)
+	match := vatMarkerRegex.FindStringSubmatchIndex(trimmed)
+	if match == nil {
+		return line, nil
+	}
+	rate, ok := vatRates[trimmed[match[2]:match[3]]]
+	if !ok {
+		return line, nil
+	}
+	return strings.TrimSpace(trimmed[:match[2]]), &rate
+}
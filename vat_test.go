@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestSplitVATMarker(t *testing.T) {
+	tests := []struct {
+		line     string
+		wantLine string
+		wantRate float64
+		wantOK   bool
+	}{
+		{"Mleko 4,99 A", "Mleko 4,99", 23, true},
+		{"Chleb 1 x4,99 4,99B", "Chleb 1 x4,99 4,99", 8, true},
+		{"Książka 39,90 C ", "Książka 39,90", 5, true},
+		{"Rabat 2,00- D", "Rabat 2,00-", 0, true},
+		{"Usługa 10,00 E", "Usługa 10,00 E", 0, false},
+		{"MLEKO 3,99", "MLEKO 3,99", 0, false},
+		{"WODA A", "WODA A", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			line, rate := splitVATMarker(tt.line)
+			if line != tt.wantLine || (rate != nil) != tt.wantOK || (rate != nil && *rate != tt.wantRate) {
+				t.Errorf("splitVATMarker(%q) = %q, %v, want %q, %v", tt.line, line, rate, tt.wantLine, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestLoadVATRates(t *testing.T) {
+	t.Cleanup(func() { vatRates = defaultVATRates })
+	tests := []struct {
+		value   string
+		wantErr bool
+		letter  string
+		want    float64
+	}{
+		{`{"a": 20, "B": 10}`, false, "A", 20},
+		{`{"A": 23}`, false, "A", 23},
+		{`not json`, true, "", 0},
+		{`{"AB": 5}`, true, "", 0},
+		{`{"1": 5}`, true, "", 0},
+		{`{"A": 100}`, true, "", 0},
+		{`{"A": -1}`, true, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			vatRates = defaultVATRates
+			t.Setenv("VAT_RATES", tt.value)
+			err := loadVATRates()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadVATRates() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && vatRates[tt.letter] != tt.want {
+				t.Errorf("rate for %s = %v, want %v", tt.letter, vatRates[tt.letter], tt.want)
+			}
+		})
+	}
+}
+
+func TestVATRateInTextItems(t *testing.T) {
+	items := textItems("Mleko 4,99 A\nWoda 1,99")
+	if len(items) != 2 {
+		t.Fatalf("got %d items %+v, want 2", len(items), items)
+	}
+	if items[0].TaxRate == nil || *items[0].TaxRate != 23 || items[0].Price != "4.99" {
+		t.Errorf("item = %+v, want 4.99 at 23%%", items[0])
+	}
+	if items[1].TaxRate != nil {
+		t.Errorf("item = %+v, want no tax rate", items[1])
+	}
+}
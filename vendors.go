@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// defaultVendorMatchThreshold is the lowest similarity at which a merchant
+// is tagged with a vendor, unless VENDOR_MATCH_THRESHOLD overrides it.
+const defaultVendorMatchThreshold = 0.8
+
+// vendor is an entry of the known-vendors list. Aliases cover names the
+// vendor also trades or prints receipts under.
+type vendor struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// vendorName is one name of a vendor, normalized for matching.
+type vendorName struct {
+	vendorID string
+	name     string
+}
+
+var (
+	knownVendors         []vendorName
+	vendorMatchThreshold float64
+)
+
+// configureVendors loads VENDOR_LIST_FILE, a JSON array such as
+// [{"id": "biedronka", "name": "Biedronka", "aliases": ["Jeronimo Martins"]}].
+// Without it no receipt is tagged.
+func configureVendors() error {
+	vendorMatchThreshold = floatFromEnv("VENDOR_MATCH_THRESHOLD", defaultVendorMatchThreshold)
+	if vendorMatchThreshold > 1 {
+		return fmt.Errorf("invalid VENDOR_MATCH_THRESHOLD %v: must be between 0 and 1", vendorMatchThreshold)
+	}
+
+	path := os.Getenv("VENDOR_LIST_FILE")
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read VENDOR_LIST_FILE: %v", err)
+	}
+	var vendors []vendor
+	if err := json.Unmarshal(data, &vendors); err != nil {
+		return fmt.Errorf("invalid VENDOR_LIST_FILE %s: %v", path, err)
+	}
+
+	var names []vendorName
+	for _, v := range vendors {
+		if v.ID == "" || v.Name == "" {
+			return fmt.Errorf("invalid VENDOR_LIST_FILE %s: every vendor needs an id and a name", path)
+		}
+		for _, name := range append([]string{v.Name}, v.Aliases...) {
+			if key := vendorMatchKey(name); key != "" {
+				names = append(names, vendorName{vendorID: v.ID, name: key})
+			}
+		}
+	}
+	knownVendors = names
+	logInfof("Loaded %d vendors from %s", len(vendors), path)
+	return nil
+}
+
+// matchVendor returns the ID of the known vendor whose name is most similar
+// to merchantName, with the similarity from 0 to 1, or an empty ID when none
+// reaches the threshold.
+func matchVendor(merchantName string) (string, float64) {
+	key := vendorMatchKey(merchantName)
+	if key == "" {
+		return "", 0
+	}
+	var bestID string
+	var bestScore float64
+	for _, candidate := range knownVendors {
+		if score := similarity(key, candidate.name); score > bestScore {
+			bestID, bestScore = candidate.vendorID, score
+		}
+	}
+	if bestScore < vendorMatchThreshold {
+		return "", 0
+	}
+	return bestID, bestScore
+}
+
+// vendorMatchKey reduces a name to lower-case letters and digits separated
+// by single spaces, after the same clean-up as merchant_name_normalized, so
+// "BIEDRONKA 123 *** SKLEP" and "Biedronka" compare equal.
+func vendorMatchKey(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(normalizeMerchantName(name)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// similarity is one minus the Levenshtein distance between a and b divided
+// by the length of the longer one, counted in runes.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+)
+
+// Set at build time, e.g.:
+//
+//	go build -ldflags "-X main.version=1.2.0 -X main.gitCommit=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	gitCommit = "dev"
+	buildTime = "dev"
+)
+
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"version":    version,
+		"git_commit": gitCommit,
+		"build_time": buildTime,
+		"go_version": runtime.Version(),
+	})
+}
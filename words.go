@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+
+	"cloud.google.com/go/documentai/apiv1/documentaipb"
+)
+
+// WordConfidence is one OCR token with Document AI's confidence in it.
+type WordConfidence struct {
+	Page       int     `json:"page"`
+	Text       string  `json:"text"`
+	Confidence float32 `json:"confidence"`
+}
+
+// WordConfidenceSummary aggregates word confidences so clients can flag
+// receipts that need re-scanning without walking every word.
+type WordConfidenceSummary struct {
+	WordCount              int     `json:"word_count"`
+	MeanConfidence         float32 `json:"mean_confidence"`
+	LowConfidenceWords     int     `json:"low_confidence_words"`
+	LowConfidenceThreshold float32 `json:"low_confidence_threshold"`
+}
+
+// defaultLowWordConfidence is the confidence below which a word counts as
+// low-confidence, unless LOW_WORD_CONFIDENCE overrides it.
+const defaultLowWordConfidence = 0.8
+
+// buildWordConfidences lists document.Pages[].Tokens in reading order with
+// their confidence, together with a summary. Pages are numbered from 1.
+func buildWordConfidences(document *documentaipb.Document) ([]WordConfidence, *WordConfidenceSummary) {
+	summary := &WordConfidenceSummary{LowConfidenceThreshold: lowWordConfidence()}
+	var words []WordConfidence
+	var total float32
+	for i, page := range document.Pages {
+		for _, token := range page.Tokens {
+			if token.Layout == nil {
+				continue
+			}
+			text := strings.TrimSpace(layoutText(document.Text, token.Layout))
+			if text == "" {
+				continue
+			}
+			words = append(words, WordConfidence{Page: i + 1, Text: text, Confidence: token.Layout.Confidence})
+			total += token.Layout.Confidence
+			if token.Layout.Confidence < summary.LowConfidenceThreshold {
+				summary.LowConfidenceWords++
+			}
+		}
+	}
+	summary.WordCount = len(words)
+	if len(words) > 0 {
+		summary.MeanConfidence = total / float32(len(words))
+	}
+	return words, summary
+}
+
+func lowWordConfidence() float32 {
+	if threshold := floatFromEnv("LOW_WORD_CONFIDENCE", defaultLowWordConfidence); threshold <= 1 {
+		return float32(threshold)
+	}
+	return defaultLowWordConfidence
+}